@@ -0,0 +1,278 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// analyses caches the result of analyze() for each struct type and naming, as an analysisResult.
+var analyses sync.Map
+
+type analysisKey struct {
+	theType reflect.Type
+	naming  keyNaming
+}
+
+type analysisResult struct {
+	err error
+	// expands is true if any of the fields are tagged with expand:"true".
+	expands bool
+}
+
+// analyze returns an error if the tags on theType (or the structs inside it) are invalid,
+// or if two of its fields would be loaded from the same key.
+//
+// This happens before anything is visited, so that a bad struct never gets half-loaded.
+// It's safe to call from multiple goroutines.
+func analyze(theType reflect.Type, naming keyNaming) error {
+	return analyzed(theType, naming).err
+}
+
+// analyzed returns everything that analyze() learns about theType.
+func analyzed(theType reflect.Type, naming keyNaming) analysisResult {
+	cacheKey := analysisKey{theType, naming}
+	if cached, ok := analyses.Load(cacheKey); ok {
+		return cached.(analysisResult)
+	}
+	a := analysis{
+		naming: naming,
+		keys:   make(map[string]string),
+		active: make(map[reflect.Type]bool),
+	}
+	a.analyzeStruct(theType, "", theType.Name())
+	problems := a.problems
+	if a.absolute != nil {
+		problems = append(problems, a.absolute.problems...)
+	}
+	var err error
+	if len(problems) > 0 {
+		err = errors.New("configs: " + theType.String() + " can't be used as a config:\n  " + strings.Join(problems, "\n  "))
+	}
+	result := analysisResult{
+		err:     err,
+		expands: a.expands || (a.absolute != nil && a.absolute.expands),
+	}
+	analyses.Store(cacheKey, result)
+	return result
+}
+
+type analysis struct {
+	naming keyNaming
+	// keys maps each key (without the prefix) to the path of the field which uses it.
+	keys map[string]string
+	// collections maps the key of each slice or map of structs to its field's path.
+	collections map[string]string
+	// active holds the types which are being analyzed, to catch recursive structs.
+	active   map[reflect.Type]bool
+	problems []string
+
+	// absolute checks the keys of fields tagged with absolute:"true", which don't start
+	// with the prefix like the others do. See absoluteKeys().
+	absolute *analysis
+	// inCollection is true while analyzing the elements of a slice or map of structs.
+	inCollection bool
+	// expands is true if any field is tagged with expand:"true".
+	expands bool
+}
+
+func (a *analysis) analyzeStruct(theType reflect.Type, keySoFar string, pathSoFar string) {
+	if a.active[theType] {
+		a.problems = append(a.problems, fmt.Sprintf("%s refers back to %s. Recursive structs aren't supported", pathSoFar, theType))
+		return
+	}
+	a.active[theType] = true
+	defer delete(a.active, theType)
+
+	plan := planFor(theType)
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		path := pathSoFar + "." + field.Name
+		if problem := field.tagProblem(a.naming); problem != "" {
+			a.problems = append(a.problems, path+" "+problem)
+			continue
+		}
+		key := a.naming.join(keySoFar, a.naming.fieldName(field))
+		if len(field.aliases) > 0 && field.kind != leafField {
+			a.problems = append(a.problems, path+" has aliases, but only fields which hold values can have them")
+		}
+		target := a
+		if field.absolute && field.kind != skippedField {
+			if a.inCollection {
+				a.problems = append(a.problems, path+" is absolute, but fields inside slices or maps of structs can't be")
+				continue
+			}
+			target = a.absoluteKeys()
+			key = a.naming.fieldName(field)
+		}
+		switch field.kind {
+		case skippedField:
+		case nestedField:
+			target.analyzeStruct(nestedType(field.Type), key, path)
+		case structSliceField, structMapField:
+			target.addKey(key, path)
+			target.addCollection(key, path)
+			// Elements have keys of their own, like "_BACKENDS_0_HOST". Those can't collide
+			// with anything outside the collection, so they're checked separately.
+			elements := analysis{
+				naming:       a.naming,
+				keys:         make(map[string]string),
+				active:       a.active,
+				inCollection: true,
+			}
+			elements.analyzeStruct(field.Type.Elem(), "", path+"[]")
+			target.problems = append(target.problems, elements.problems...)
+			target.expands = target.expands || elements.expands
+		default:
+			target.expands = target.expands || field.expand
+			target.addKey(key, path)
+			for _, fieldAlias := range field.aliases {
+				if problem := validateName(fieldAlias.name, "alias", a.naming); problem != "" {
+					target.problems = append(target.problems, path+" "+problem)
+					continue
+				}
+				target.addKey(a.naming.aliasKey(key, field, fieldAlias), path)
+			}
+		}
+	}
+}
+
+// absoluteKeys returns the analysis which checks absolute keys for collisions.
+// Absolute keys don't start with the prefix, so they can only collide with each other.
+func (a *analysis) absoluteKeys() *analysis {
+	if a.absolute == nil {
+		a.absolute = &analysis{
+			naming: a.naming,
+			keys:   make(map[string]string),
+			active: a.active,
+		}
+		a.absolute.absolute = a.absolute
+	}
+	return a.absolute
+}
+
+func (a *analysis) addKey(key string, path string) {
+	if existing, ok := a.keys[key]; ok {
+		a.problems = append(a.problems, fmt.Sprintf("%s and %s both use the key %q", existing, path, key))
+		return
+	}
+	for _, collection := range sortedKeys(a.collections) {
+		if strings.HasPrefix(key, collection+a.naming.separator) {
+			a.problems = append(a.problems, fmt.Sprintf("%s uses the key %q, which looks like an element of %s", path, key, a.collections[collection]))
+		}
+	}
+	a.keys[key] = path
+}
+
+func (a *analysis) addCollection(key string, path string) {
+	if a.collections == nil {
+		a.collections = make(map[string]string)
+	}
+	for _, otherKey := range sortedKeys(a.keys) {
+		if strings.HasPrefix(otherKey, key+a.naming.separator) {
+			a.problems = append(a.problems, fmt.Sprintf("%s uses the key %q, which looks like an element of %s", a.keys[otherKey], otherKey, path))
+		}
+	}
+	a.collections[key] = path
+}
+
+// sortedKeys returns the keys of m in order, so that problems are always reported the same way.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// structType returns the struct type whose fields get visited for a nestedField,
+// structSliceField or structMapField.
+func (f *fieldPlan) structType() reflect.Type {
+	if f.kind == nestedField {
+		return nestedType(f.Type)
+	}
+	return f.Type.Elem()
+}
+
+// hasTaggedFields returns true if any of theType's fields have an environment tag.
+func hasTaggedFields(theType reflect.Type) bool {
+	plan := planFor(theType)
+	for i := range plan.fields {
+		if plan.fields[i].kind != skippedField {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedType returns the struct type of a nestedField.
+func nestedType(theType reflect.Type) reflect.Type {
+	if theType.Kind() == reflect.Ptr {
+		return theType.Elem()
+	}
+	return theType
+}
+
+// tagProblem describes what's wrong with the field's "environment" tag, or returns ""
+// if it's valid.
+func (f *fieldPlan) tagProblem(naming keyNaming) string {
+	if f.kind == skippedField {
+		return ""
+	}
+	if !f.IsExported() {
+		return "has an environment tag, but isn't exported"
+	}
+	if f.format != "" && f.format != jsonFormat {
+		return fmt.Sprintf(`has format:%q, but the only supported format is "json"`, f.format)
+	}
+	if f.kind == leafField && !canParse(f) {
+		return fmt.Sprintf("has the type %v, which can't be loaded", f.Type)
+	}
+	// Structs from other packages, like time.Time, look like nested structs. Without any
+	// tagged fields of their own, they'd be skipped without a word.
+	if f.kind != leafField && !hasTaggedFields(f.structType()) {
+		return fmt.Sprintf("has the type %v, which can't be loaded", f.Type)
+	}
+	if tag, ok := f.Tag.Lookup("base"); ok {
+		if _, valid := baseTag(f.StructField); !valid {
+			return fmt.Sprintf(`has base:%q, but the base must be 0 or between 2 and 36`, tag)
+		}
+	}
+	if problem := networkTagProblem(f.StructField); problem != "" {
+		return problem
+	}
+	if f.encoding != "" {
+		if _, ok := byteEncodings[f.encoding]; !ok {
+			return fmt.Sprintf("has encoding:%q, but the encoding must be one of %s", f.encoding, byteEncodingNames())
+		}
+		if !isBytes(f.Type) || f.format == jsonFormat {
+			return "has an encoding tag, but only []byte fields can have one"
+		}
+	}
+	return validateName(f.name, "environment tag", naming)
+}
+
+// validateName describes what's wrong with a name from a tag, or returns "" if it's valid.
+// kind says what the name is for in the description, like "environment tag".
+func validateName(name string, kind string, naming keyNaming) string {
+	if name == "" {
+		return "has an empty " + kind
+	}
+	if strings.HasPrefix(name, naming.separator) || strings.HasSuffix(name, naming.separator) {
+		return fmt.Sprintf(`has the %s %q, which can't start or end with %q`, kind, name, naming.separator)
+	}
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' && !strings.ContainsRune(naming.separator, r) {
+			allowed := "letters, digits and underscores"
+			if naming.separator != "_" {
+				allowed = fmt.Sprintf("letters, digits, underscores and %q", naming.separator)
+			}
+			return fmt.Sprintf("has the %s %q, which can only contain %s", kind, name, allowed)
+		}
+	}
+	return ""
+}
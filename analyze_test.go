@@ -0,0 +1,120 @@
+package configs_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type CollidingConfig struct {
+	AB    string    `environment:"A_B"`
+	A     *Inner    `environment:"A"`
+	Empty string    `environment:""`
+	Dash  string    `environment:"MY-KEY"`
+	Edge  string    `environment:"_EDGE"`
+	Items []Backend `environment:"ITEMS"`
+	Item  string    `environment:"ITEMS_0_HOST"`
+	inner string    `environment:"INNER"`
+}
+
+type Inner struct {
+	B string `environment:"B"`
+}
+
+type RecursiveConfig struct {
+	Value int              `environment:"VALUE"`
+	Next  *RecursiveConfig `environment:"NEXT"`
+}
+
+func TestStructProblems(t *testing.T) {
+	defer setEnv(t, "MY_A_B", "value")()
+	cfg := CollidingConfig{}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `configs: configs_test.CollidingConfig can't be used as a config:`)
+	assertStringContains(t, msg, `CollidingConfig.AB and CollidingConfig.A.B both use the key "A_B"`)
+	assertStringContains(t, msg, `CollidingConfig.Empty has an empty environment tag`)
+	assertStringContains(t, msg, `CollidingConfig.Dash has the environment tag "MY-KEY", which can only contain letters, digits and underscores`)
+	assertStringContains(t, msg, `CollidingConfig.Edge has the environment tag "_EDGE", which can't start or end with "_"`)
+	assertStringContains(t, msg, `CollidingConfig.Item uses the key "ITEMS_0_HOST", which looks like an element of CollidingConfig.Items`)
+	assertStringContains(t, msg, `CollidingConfig.inner has an environment tag, but isn't exported`)
+	if cfg.AB != "" {
+		t.Error("Nothing should be loaded into a struct with problems")
+	}
+}
+
+func TestEnsureKeepsStructProblems(t *testing.T) {
+	var cfg CollidingConfig
+	loader := configs.NewLoader()
+	loaded := loader.Load(&cfg, "MY")
+	err := configs.Ensure(loaded, "MY_A_B", false, "must be set")
+	err = loader.Ensure(err, &cfg, "MY", "MY_A_B", false, "must be set")
+	if err != loaded {
+		t.Errorf("Expected Ensure() to return the Load() error unchanged. Got %v", err)
+	}
+	assertStringContains(t, err.Error(), `CollidingConfig.AB and CollidingConfig.A.B both use the key "A_B"`)
+}
+
+func TestRecursiveStructs(t *testing.T) {
+	err := configs.LoadWithPrefix(&RecursiveConfig{}, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), "RecursiveConfig.Next refers back to configs_test.RecursiveConfig")
+}
+
+func TestUntaggedAndNestedStructs(t *testing.T) {
+	defer setEnv(t, "MY_MAIN_PORT", "80")()
+	defer setEnv(t, "MY_", "ignored")()
+	cfg := struct {
+		Main       Server `environment:"MAIN"`
+		Untagged   string
+		Skipped    string `environment:"-"`
+		unexported int
+	}{}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Main.Port)
+	assertStringsEqual(t, "", cfg.Untagged)
+	assertStringsEqual(t, "", cfg.Skipped)
+}
+
+func TestUnsupportedTypes(t *testing.T) {
+	type Unsupported struct {
+		Int64    int64               `environment:"INT64"`
+		Lists    map[string][]string `environment:"LISTS"`
+		Pointer  *string             `environment:"POINTER"`
+		Floats   []float64           `environment:"FLOATS"`
+		BigKeys  map[*big.Int]int    `environment:"BIG_KEYS"`
+		Time     time.Time           `environment:"TIME"`
+		Deadline *time.Time          `environment:"DEADLINE"`
+		Times    []time.Time         `environment:"TIMES"`
+		Duration time.Duration       `environment:"DURATION"`
+		JSON     map[string][]string `environment:"JSON" format:"json"`
+	}
+	err := configs.NewLoader(configs.WithSource(configs.MapSource(nil))).Load(&Unsupported{}, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "Unsupported.Int64 has the type int64, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Lists has the type map[string][]string, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Pointer has the type *string, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Floats has the type []float64, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.BigKeys has the type map[*big.Int]int, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Time has the type time.Time, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Deadline has the type *time.Time, which can't be loaded")
+	assertStringContains(t, msg, "Unsupported.Times has the type []time.Time, which can't be loaded")
+	assertNotStringContains(t, msg, "Unsupported.Duration")
+	assertNotStringContains(t, msg, "Unsupported.JSON")
+}
@@ -0,0 +1,218 @@
+// Command envconfig checks and documents the environment variables used by a config struct,
+// without building the program which uses it.
+//
+// Usage:
+//
+//	envconfig check [flags] [file.env]    validate the environment, or a .env file
+//	envconfig docs [flags]                print a table of the variables
+//	envconfig diff [flags] old.env new.env compare two .env files
+//
+// Every command reads the struct from the Go files in -dir. Run "envconfig <command> -h"
+// to see the flags.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+const usage = `usage:
+  envconfig check [flags] [file.env]     validate the environment, or a .env file
+  envconfig docs [flags]                 print a table of the variables
+  envconfig diff [flags] old.env new.env compare two .env files
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command in args and returns the exit code.
+// It's 1 if a check failed or a diff found changes, and 2 for any other error.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd := command{stdout: stdout, stderr: stderr}
+	flags := flag.NewFlagSet("envconfig "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cmd.dir, "dir", ".", "the directory of the package which declares the struct")
+	flags.StringVar(&cmd.typeName, "type", "Config", "the name of the struct")
+	flags.StringVar(&cmd.prefix, "prefix", "", "the prefix passed to Load")
+
+	var execute func(args []string) (int, error)
+	switch args[0] {
+	case "check":
+		flags.StringVar(&cmd.unknown, "unknown", "reject", `what to do with unknown keys: "ignore", "warn" or "reject"`)
+		execute = cmd.check
+	case "docs":
+		flags.StringVar(&cmd.format, "format", "markdown", `the output format: "markdown", "env" or "json"`)
+		execute = cmd.docs
+	case "diff":
+		execute = cmd.diff
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	configType, err := cmd.structType()
+	if err != nil {
+		fmt.Fprintf(stderr, "envconfig: %v\n", err)
+		return 2
+	}
+	cmd.configType = configType
+	code, err := execute(flags.Args())
+	if err != nil {
+		fmt.Fprintf(stderr, "envconfig: %v\n", err)
+	}
+	return code
+}
+
+// command holds the flags shared by all the commands.
+type command struct {
+	dir      string
+	typeName string
+	prefix   string
+	unknown  string
+	format   string
+
+	configType reflect.Type
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func (c *command) structType() (reflect.Type, error) {
+	builder, err := parsePackage(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	return builder.structType(c.typeName)
+}
+
+// load loads a new config from src. Loading is all-or-nothing, so if there were errors,
+// the container is returned with its zero values.
+func (c *command) load(src configs.Source, opts ...configs.Option) (interface{}, error) {
+	container := reflect.New(c.configType).Interface()
+	opts = append([]configs.Option{
+		configs.WithSource(src),
+		configs.WithLogger(log.New(c.stderr, "", 0)),
+	}, opts...)
+	return container, configs.NewLoader(opts...).Load(container, c.prefix)
+}
+
+func (c *command) check(args []string) (int, error) {
+	policies := map[string]configs.UnknownKeyPolicy{
+		"ignore": configs.IgnoreUnknownKeys,
+		"warn":   configs.WarnOnUnknownKeys,
+		"reject": configs.RejectUnknownKeys,
+	}
+	policy, ok := policies[c.unknown]
+	if !ok {
+		return 2, fmt.Errorf("-unknown must be ignore, warn or reject, but got %q", c.unknown)
+	}
+	src := configs.Environment()
+	switch len(args) {
+	case 0:
+	case 1:
+		file, err := configs.EnvFile(args[0])
+		if err != nil {
+			return 2, err
+		}
+		src = file
+	default:
+		return 2, errors.New("check takes at most one .env file")
+	}
+	if _, err := c.load(src, configs.WithUnknownKeys(policy)); err != nil {
+		fmt.Fprint(c.stdout, err)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (c *command) docs(args []string) (int, error) {
+	if len(args) != 0 {
+		return 2, errors.New("docs doesn't take any arguments")
+	}
+	writers := map[string]func(io.Writer, []configs.Description) error{
+		"markdown": configs.WriteMarkdown,
+		"env":      configs.WriteEnvExample,
+		"json":     configs.WriteJSON,
+	}
+	write, ok := writers[c.format]
+	if !ok {
+		return 2, fmt.Errorf("-format must be markdown, env or json, but got %q", c.format)
+	}
+	// Describe falls back to the "default" tags of empty fields, so they show up in the docs.
+	descriptions, err := configs.Describe(reflect.New(c.configType).Interface(), c.prefix)
+	if err != nil {
+		return 2, err
+	}
+	if err := write(c.stdout, descriptions); err != nil {
+		return 2, err
+	}
+	return 0, nil
+}
+
+func (c *command) diff(args []string) (int, error) {
+	if len(args) != 2 {
+		return 2, errors.New("diff needs two .env files")
+	}
+	var files [2]configs.Source
+	var descriptions []configs.Description
+	for i, path := range args {
+		file, err := configs.EnvFile(path)
+		if err != nil {
+			return 2, err
+		}
+		files[i] = file
+		// Loading finds any elements of slices and maps of structs, so their keys get compared too.
+		container, err := c.load(file)
+		if err != nil {
+			return 2, fmt.Errorf("%s is invalid: %v", path, err)
+		}
+		described, err := configs.Describe(container, c.prefix)
+		if err != nil {
+			return 2, err
+		}
+		descriptions = append(descriptions, described...)
+	}
+
+	changed := false
+	seen := make(map[string]bool)
+	for _, description := range descriptions {
+		if seen[description.Key] {
+			continue
+		}
+		seen[description.Key] = true
+		oldValue, oldSet := files[0].Lookup(description.Key)
+		newValue, newSet := files[1].Lookup(description.Key)
+		if oldSet == newSet && oldValue == newValue {
+			continue
+		}
+		changed = true
+		if description.Secret {
+			oldValue, newValue = "<redacted>", "<redacted>"
+		}
+		switch {
+		case !oldSet:
+			fmt.Fprintf(c.stdout, "+ %s=%s\n", description.Key, newValue)
+		case !newSet:
+			fmt.Fprintf(c.stdout, "- %s=%s\n", description.Key, oldValue)
+		default:
+			fmt.Fprintf(c.stdout, "~ %s: %s -> %s\n", description.Key, oldValue, newValue)
+		}
+	}
+	if changed {
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runForTest(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func writeEnvFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.env")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheck(t *testing.T) {
+	valid := writeEnvFile(t, "MY_PASSWORD=shh\nMY_PORT=80\nMY_BACKENDS_0_HOST=a\nMY_DB_URL=postgres://db\n")
+	code, stdout, stderr := runForTest(t, "check", "-dir", "testdata/app", "-prefix", "MY", valid)
+	if code != 0 {
+		t.Errorf("Expected check to pass, but got %d: %s%s", code, stdout, stderr)
+	}
+
+	invalid := writeEnvFile(t, "MY_PORT=eighty\nMY_PROT=80\nMY_MAX_BODY=lots\nMY_FLAGS={\"beta\":\nMY_WEBHOOK=example.com/hook\n")
+	code, stdout, _ = runForTest(t, "check", "-dir", "testdata/app", "-prefix", "MY", invalid)
+	if code != 1 {
+		t.Errorf("Expected check to fail with code 1, but got %d", code)
+	}
+	for _, expected := range []string{
+		`MY_PORT must be an int: got "eighty"`,
+		"MY_PASSWORD is required",
+		"MY_PROT isn't used by the config (did you mean MY_PORT?)",
+		"MY_MAX_BODY must be a size",
+		"MY_FLAGS must be valid JSON, but has an error at byte 8: unexpected end of JSON input",
+		"MY_WEBHOOK must be a URL: missing scheme",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected check output to contain %q, but got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	code, stdout, stderr := runForTest(t, "docs", "-dir", "testdata/app", "-prefix", "MY", "-format", "env")
+	if code != 0 {
+		t.Errorf("Expected docs to pass, but got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"# The port to listen on.\n# int\nMY_PORT=8080\n",
+		"# string, required, secret\nMY_PASSWORD=\n",
+		"# configs.ByteSize\nMY_MAX_BODY=\n",
+		"# *big.Int\nMY_SEED=\n",
+		"MY_DB_URL=",
+		"MY_FLAGS=\n",
+		"# *url.URL\nMY_WEBHOOK=\n",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected docs to contain %q, but got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := writeEnvFile(t, "MY_PASSWORD=old\nMY_PORT=80\nMY_HOSTS=a\nMY_BACKENDS_0_HOST=a\n")
+	new := writeEnvFile(t, "MY_PASSWORD=new\nMY_PORT=80\nMY_DB_URL=postgres://db\nMY_BACKENDS_0_HOST=b\n")
+	code, stdout, stderr := runForTest(t, "diff", "-dir", "testdata/app", "-prefix", "MY", old, new)
+	if code != 1 {
+		t.Errorf("Expected diff to find changes, but got %d: %s", code, stderr)
+	}
+	expected := "~ MY_PASSWORD: <redacted> -> <redacted>\n" +
+		"- MY_HOSTS=a\n" +
+		"+ MY_DB_URL=postgres://db\n" +
+		"~ MY_BACKENDS_0_HOST: a -> b\n"
+	if stdout != expected {
+		t.Errorf("Expected diff output:\n%s\nbut got:\n%s", expected, stdout)
+	}
+
+	code, stdout, _ = runForTest(t, "diff", "-dir", "testdata/app", "-prefix", "MY", old, old)
+	if code != 0 || stdout != "" {
+		t.Errorf("Expected no changes, but got %d: %s", code, stdout)
+	}
+}
+
+func TestUnsupportedStruct(t *testing.T) {
+	code, _, stderr := runForTest(t, "docs", "-dir", "testdata/app", "-type", "Missing")
+	if code != 2 || !strings.Contains(stderr, "type Missing isn't declared in the package") {
+		t.Errorf("Expected an error about the missing type, but got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+const configsPath = "github.com/wikisophia/go-environment-configs"
+
+// basicTypes are the builtin types which the configs package can load.
+var basicTypes = map[string]reflect.Type{
+	"string": reflect.TypeOf(""),
+	"bool":   reflect.TypeOf(false),
+	"int":    reflect.TypeOf(0),
+	"uint8":  reflect.TypeOf(uint8(0)),
+	"byte":   reflect.TypeOf(uint8(0)),
+	"uint16": reflect.TypeOf(uint16(0)),
+	"uint32": reflect.TypeOf(uint32(0)),
+	"uint64": reflect.TypeOf(uint64(0)),
+}
+
+// qualifiedTypes are the types from other packages which the configs package can load,
+// keyed by import path and name.
+var qualifiedTypes = map[string]reflect.Type{
+	"math/big.Int":            reflect.TypeOf(big.Int{}),
+	"time.Duration":           reflect.TypeOf(time.Duration(0)),
+	"net/url.URL":             reflect.TypeOf(url.URL{}),
+	"net.IP":                  reflect.TypeOf(net.IP{}),
+	"net.IPNet":               reflect.TypeOf(net.IPNet{}),
+	"net/netip.Addr":          reflect.TypeOf(netip.Addr{}),
+	"net/netip.Prefix":        reflect.TypeOf(netip.Prefix{}),
+	"net/netip.AddrPort":      reflect.TypeOf(netip.AddrPort{}),
+	configsPath + ".ByteSize": reflect.TypeOf(configs.ByteSize(0)),
+	configsPath + ".Percent":  reflect.TypeOf(configs.Percent(0)),
+	configsPath + ".HostPort": reflect.TypeOf(configs.HostPort{}),
+}
+
+// declaration is a type declared in the package, along with the imports of its file.
+type declaration struct {
+	spec    *ast.TypeSpec
+	imports map[string]string
+}
+
+// typeBuilder recreates the types declared in a package's source code with reflection,
+// so that they can be loaded without compiling the package.
+//
+// Named types become unnamed ones with the same structure, and untagged fields are dropped.
+// This doesn't change which keys the configs package uses.
+type typeBuilder struct {
+	declarations map[string]declaration
+	// building holds the names of the types being built, to catch recursive structs.
+	building map[string]bool
+}
+
+// parsePackage reads the Go files in dir, excluding tests.
+func parsePackage(dir string) (*typeBuilder, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	b := &typeBuilder{
+		declarations: make(map[string]declaration),
+		building:     make(map[string]bool),
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			imports := fileImports(file)
+			for _, decl := range file.Decls {
+				genDecl, ok := decl.(*ast.GenDecl)
+				if !ok || genDecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range genDecl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					b.declarations[typeSpec.Name.Name] = declaration{typeSpec, imports}
+				}
+			}
+		}
+	}
+	return b, nil
+}
+
+// fileImports maps the names which a file uses for its imports to their paths.
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if path == configsPath {
+			name = "configs"
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// structType builds the struct type declared as name.
+func (b *typeBuilder) structType(name string) (reflect.Type, error) {
+	decl, ok := b.declarations[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s isn't declared in the package", name)
+	}
+	if _, ok := decl.spec.Type.(*ast.StructType); !ok {
+		return nil, fmt.Errorf("type %s isn't a struct", name)
+	}
+	return b.build(&ast.Ident{Name: name}, decl.imports)
+}
+
+func (b *typeBuilder) build(expr ast.Expr, imports map[string]string) (reflect.Type, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicTypes[expr.Name]; ok {
+			return basic, nil
+		}
+		decl, ok := b.declarations[expr.Name]
+		if !ok {
+			return nil, fmt.Errorf("type %s isn't supported", expr.Name)
+		}
+		if b.building[expr.Name] {
+			return nil, fmt.Errorf("type %s refers back to itself", expr.Name)
+		}
+		b.building[expr.Name] = true
+		defer delete(b.building, expr.Name)
+		return b.build(decl.spec.Type, decl.imports)
+	case *ast.SelectorExpr:
+		pkg, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("type %s isn't supported", exprString(expr))
+		}
+		if qualified, ok := qualifiedTypes[imports[pkg.Name]+"."+expr.Sel.Name]; ok {
+			return qualified, nil
+		}
+		return nil, fmt.Errorf("type %s isn't supported", exprString(expr))
+	case *ast.StarExpr:
+		elem, err := b.build(expr.X, imports)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(elem), nil
+	case *ast.ArrayType:
+		if expr.Len != nil {
+			return nil, fmt.Errorf("arrays like %s aren't supported", exprString(expr))
+		}
+		elem, err := b.build(expr.Elt, imports)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case *ast.MapType:
+		key, err := b.build(expr.Key, imports)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := b.build(expr.Value, imports)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, elem), nil
+	case *ast.StructType:
+		return b.buildStruct(expr, imports)
+	default:
+		return nil, fmt.Errorf("type %s isn't supported", exprString(expr))
+	}
+}
+
+func (b *typeBuilder) buildStruct(expr *ast.StructType, imports map[string]string) (reflect.Type, error) {
+	var fields []reflect.StructField
+	for _, field := range expr.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tagValue, _ := strconv.Unquote(field.Tag.Value)
+		tag := reflect.StructTag(tagValue)
+		if name, ok := tag.Lookup("environment"); !ok || name == "-" {
+			continue
+		}
+		// Any type can hold JSON, so there's no need to rebuild it. Loading it as a
+		// json.RawMessage still checks that the value is valid JSON.
+		fieldType := reflect.TypeOf(json.RawMessage(nil))
+		if tag.Get("format") != "json" {
+			var err error
+			if fieldType, err = b.build(field.Type, imports); err != nil {
+				return nil, err
+			}
+		}
+		names := field.Names
+		if len(names) == 0 {
+			// Embedded fields are named after their type.
+			names = []*ast.Ident{{Name: embeddedName(field.Type)}}
+		}
+		for _, name := range names {
+			if !name.IsExported() {
+				return nil, fmt.Errorf("field %s has an environment tag, but isn't exported", name.Name)
+			}
+			fields = append(fields, reflect.StructField{
+				Name: name.Name,
+				Type: fieldType,
+				Tag:  tag,
+			})
+		}
+	}
+	return reflect.StructOf(fields), nil
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.Ident:
+		return expr.Name
+	default:
+		return ""
+	}
+}
+
+// exprString formats a type expression for error messages.
+func exprString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return exprString(expr.X) + "." + expr.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(expr.X)
+	case *ast.ArrayType:
+		if expr.Len != nil {
+			return "[...]" + exprString(expr.Elt)
+		}
+		return "[]" + exprString(expr.Elt)
+	case *ast.MapType:
+		return "map[" + exprString(expr.Key) + "]" + exprString(expr.Value)
+	case *ast.StructType:
+		return "struct{...}"
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
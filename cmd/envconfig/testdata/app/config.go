@@ -0,0 +1,35 @@
+package app
+
+import (
+	"math/big"
+	"net/url"
+
+	cfgs "github.com/wikisophia/go-environment-configs"
+)
+
+type Config struct {
+	Port     int             `environment:"PORT" default:"8080" desc:"The port to listen on."`
+	Password string          `environment:"PASSWORD" required:"true"`
+	Hosts    []string        `environment:"HOSTS"`
+	Limits   map[string]int  `environment:"LIMITS"`
+	MaxBody  cfgs.ByteSize   `environment:"MAX_BODY"`
+	Seed     *big.Int        `environment:"SEED"`
+	Database Database        `environment:"DB"`
+	Webhook  *url.URL        `environment:"WEBHOOK" require:"scheme,host"`
+	Backends []Backend       `environment:"BACKENDS"`
+	Flags    map[string]Flag `environment:"FLAGS" format:"json"`
+	ignored  chan int
+}
+
+type Database struct {
+	URL string `environment:"URL"`
+}
+
+type Backend struct {
+	Host string `environment:"HOST"`
+}
+
+type Flag struct {
+	Enabled bool    `json:"enabled"`
+	Percent float64 `json:"percent"`
+}
@@ -0,0 +1,180 @@
+// Package configstest helps test structs which are loaded with the configs package.
+//
+// Most config structs only need one test:
+//
+//	func TestConfig(t *testing.T) {
+//		configstest.AssertValid(t, &Config{}, "MYAPP")
+//	}
+//
+// Values can be loaded from a map, so tests don't need to change the process' environment:
+//
+//	cfg := configstest.Load[Config](t, "MYAPP", map[string]string{
+//		"MYAPP_PORT": "80",
+//	})
+package configstest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+// SecretPatterns are the parts of a key which make it look like a secret to AssertSecretsRedacted.
+// They're compared without case.
+var SecretPatterns = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"api_key",
+	"private_key",
+	"credential",
+}
+
+// Load loads a T from values, and fails the test if there's an error.
+// Any opts are passed to configs.NewLoader, after the one which sets the source.
+func Load[T any](t testing.TB, prefix string, values map[string]string, opts ...configs.Option) T {
+	t.Helper()
+	opts = append([]configs.Option{configs.WithSource(configs.MapSource(values))}, opts...)
+	cfg, err := configs.Load[T](prefix, opts...)
+	if err != nil {
+		t.Fatalf("configstest: failed to load a %T: %v", cfg, err)
+	}
+	return cfg
+}
+
+// AssertValid runs all the other assertions on container, which must be a pointer to a struct.
+// The opts should match the ones used to load it outside of tests.
+func AssertValid(t testing.TB, container interface{}, prefix string, opts ...configs.Option) {
+	t.Helper()
+	AssertAllTagged(t, container)
+	// The other checks would all fail in the same way.
+	if !checkCollisions(t, container, prefix, opts) {
+		return
+	}
+	AssertDefaultsValid(t, container, prefix, opts...)
+	AssertSecretsRedacted(t, container, prefix, opts...)
+}
+
+// AssertAllTagged fails the test if any exported field on container doesn't have an
+// "environment" tag. Fields which are deliberately left out should be tagged with environment:"-".
+//
+// Structs inside container are checked too, unless none of their fields have tags.
+// Those are assumed to be values, like a big.Int.
+func AssertAllTagged(t testing.TB, container interface{}) {
+	t.Helper()
+	theType := reflect.TypeOf(container)
+	if theType == nil || theType.Kind() != reflect.Ptr || theType.Elem().Kind() != reflect.Struct {
+		t.Errorf("configstest: container must be a pointer to a struct, but got a %v", theType)
+		return
+	}
+	for _, path := range untaggedFields(theType.Elem(), theType.Elem().Name(), make(map[reflect.Type]bool)) {
+		t.Errorf(`configstest: %s doesn't have an environment tag. Use environment:"-" if it shouldn't be loaded`, path)
+	}
+}
+
+func untaggedFields(theType reflect.Type, pathSoFar string, seen map[reflect.Type]bool) []string {
+	if seen[theType] {
+		return nil
+	}
+	seen[theType] = true
+	var untagged []string
+	for i := 0; i < theType.NumField(); i++ {
+		field := theType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := pathSoFar + "." + field.Name
+		name, ok := field.Tag.Lookup("environment")
+		if !ok {
+			untagged = append(untagged, path)
+			continue
+		}
+		if field.Tag.Get("format") == "json" {
+			// The JSON's structure comes from "json" tags instead.
+			continue
+		}
+		if inner := innerStruct(field.Type); name != "-" && inner != nil && hasTags(inner) {
+			untagged = append(untagged, untaggedFields(inner, path, seen)...)
+		}
+	}
+	return untagged
+}
+
+// innerStruct returns the struct type inside of a struct, pointer, slice or map type, or nil.
+func innerStruct(theType reflect.Type) reflect.Type {
+	switch theType.Kind() {
+	case reflect.Struct:
+		return theType
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if theType.Elem().Kind() == reflect.Struct {
+			return theType.Elem()
+		}
+	}
+	return nil
+}
+
+func hasTags(theType reflect.Type) bool {
+	for i := 0; i < theType.NumField(); i++ {
+		if _, ok := theType.Field(i).Tag.Lookup("environment"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertNoCollisions fails the test if container's tags are invalid, or if two fields would be
+// loaded from the same key.
+func AssertNoCollisions(t testing.TB, container interface{}, prefix string, opts ...configs.Option) {
+	t.Helper()
+	checkCollisions(t, container, prefix, opts)
+}
+
+// checkCollisions works like AssertNoCollisions, but returns false if the test failed.
+func checkCollisions(t testing.TB, container interface{}, prefix string, opts []configs.Option) bool {
+	t.Helper()
+	if _, err := configs.NewLoader(opts...).Describe(container, prefix); err != nil {
+		t.Errorf("configstest: %v", err)
+		return false
+	}
+	return true
+}
+
+// AssertDefaultsValid fails the test if any of the "default" tags on container can't be parsed.
+func AssertDefaultsValid(t testing.TB, container interface{}, prefix string, opts ...configs.Option) {
+	t.Helper()
+	if err := configs.NewLoader(opts...).CheckDefaults(container, prefix); err != nil {
+		t.Errorf("configstest: %v", err)
+	}
+}
+
+// AssertSecretsRedacted fails the test if a key looks like a secret, but its value
+// would be logged. See SecretPatterns.
+//
+// Only the elements which are already in container's slices and maps of structs are checked.
+func AssertSecretsRedacted(t testing.TB, container interface{}, prefix string, opts ...configs.Option) {
+	t.Helper()
+	descriptions, err := configs.NewLoader(opts...).Describe(container, prefix)
+	if err != nil {
+		// AssertNoCollisions reports these.
+		return
+	}
+	for _, description := range descriptions {
+		if !description.Secret && looksSecret(description.Key) {
+			t.Errorf(`configstest: %s looks like a secret, but it isn't redacted. Tag it with secret:"true"`, description.Key)
+		}
+	}
+}
+
+func looksSecret(key string) bool {
+	lower := strings.ToLower(key)
+	for _, pattern := range SecretPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
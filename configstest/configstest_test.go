@@ -0,0 +1,112 @@
+package configstest_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+	"github.com/wikisophia/go-environment-configs/configstest"
+)
+
+type Config struct {
+	Port     int       `environment:"PORT" default:"8080"`
+	Password string    `environment:"PASSWORD"`
+	Seed     big.Int   `environment:"SEED"`
+	Backends []Backend `environment:"BACKENDS"`
+	Internal string    `environment:"-"`
+	private  string
+}
+
+type Backend struct {
+	Host string `environment:"HOST"`
+}
+
+type BadConfig struct {
+	Port     int    `environment:"PORT" default:"eighty"`
+	APIToken string `environment:"API_TOKEN"`
+	Untagged string
+	Nested   struct {
+		Host  string `environment:"HOST"`
+		Other string
+	} `environment:"NESTED"`
+	NestedHost string `environment:"NESTED_HOST"`
+}
+
+// recorder is a testing.TB which remembers failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+	fatal    bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func (r *recorder) String() string {
+	return strings.Join(r.failures, "\n")
+}
+
+func TestLoad(t *testing.T) {
+	cfg := configstest.Load[Config](t, "MY", map[string]string{
+		"MY_PASSWORD":        "shh",
+		"MY_BACKENDS_0_HOST": "a",
+	})
+	if cfg.Port != 8080 || cfg.Password != "shh" || len(cfg.Backends) != 1 {
+		t.Errorf("Got unexpected config: %#v", cfg)
+	}
+
+	r := &recorder{}
+	configstest.Load[Config](r, "MY", map[string]string{"MY_PORT": "eighty"})
+	if !r.fatal || !strings.Contains(r.String(), `MY_PORT must be an int: got "eighty"`) {
+		t.Errorf("Expected a fatal error about MY_PORT, but got: %s", r)
+	}
+}
+
+func TestAssertValid(t *testing.T) {
+	configstest.AssertValid(t, &Config{}, "MY")
+}
+
+func TestAssertValidFailures(t *testing.T) {
+	r := &recorder{}
+	configstest.AssertValid(r, &BadConfig{}, "MY", configs.WithRedactions("secret"))
+	for _, expected := range []string{
+		"configstest: BadConfig.Untagged doesn't have an environment tag",
+		"configstest: BadConfig.Nested.Other doesn't have an environment tag",
+		`BadConfig.Nested.Host and BadConfig.NestedHost both use the key "NESTED_HOST"`,
+	} {
+		if !strings.Contains(r.String(), expected) {
+			t.Errorf("Expected failures to contain %q, but got:\n%s", expected, r)
+		}
+	}
+
+	r = &recorder{}
+	type BadDefaults struct {
+		Port     int    `environment:"PORT" default:"eighty"`
+		APIToken string `environment:"API_TOKEN"`
+	}
+	configstest.AssertValid(r, &BadDefaults{}, "MY")
+	for _, expected := range []string{
+		`MY_PORT has an invalid default "eighty": must be an int`,
+		`configstest: MY_API_TOKEN looks like a secret, but it isn't redacted`,
+	} {
+		if !strings.Contains(r.String(), expected) {
+			t.Errorf("Expected failures to contain %q, but got:\n%s", expected, r)
+		}
+	}
+
+	r = &recorder{}
+	configstest.AssertSecretsRedacted(r, &BadDefaults{}, "MY", configs.WithRedactions("token"))
+	if len(r.failures) > 0 {
+		t.Errorf("Expected WithRedactions to hide the token, but got:\n%s", r)
+	}
+}
@@ -0,0 +1,59 @@
+package configs
+
+import (
+	"math/big"
+	"reflect"
+)
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// deepCopy returns a copy of value which doesn't share any pointers, slices or maps with it.
+// Only exported fields are deep-copied. Unexported ones are copied as-is.
+func deepCopy(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(deepCopy(value.Elem()))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		if value.Type() == bigIntType {
+			// big.Ints share their internal slice when copied by value.
+			original := reflect.New(bigIntType)
+			original.Elem().Set(value)
+			copied.Addr().Interface().(*big.Int).Set(original.Interface().(*big.Int))
+			return copied
+		}
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(value.Field(i)))
+			}
+		}
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,147 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Description describes a single key which can be loaded into a struct.
+type Description struct {
+	// Key is the full name of the key, including the prefix.
+	Key string `json:"key"`
+	// Type is the Go type which the value will be loaded into.
+	Type string `json:"type"`
+	// Default is the value which the field will have if the key isn't set. It comes
+	// from the field's current value or, if that's empty, from its "default" tag.
+	// It's always empty for secrets, so that they don't end up in documentation.
+	Default string `json:"default,omitempty"`
+	// Required is true if the field is tagged with required:"true".
+	Required bool `json:"required"`
+	// Secret is true if the value won't be logged or printed in errors.
+	Secret bool `json:"secret"`
+	// Desc comes from the field's "desc" tag.
+	Desc string `json:"description,omitempty"`
+}
+
+// Describe lists the keys which can be loaded into container, in the order they're visited.
+// Slices and maps of structs only include the elements which container already has.
+func Describe(container interface{}, prefix string) ([]Description, error) {
+	return defaultLoader.Describe(container, prefix)
+}
+
+// Describe lists the keys which can be loaded into container, in the order they're visited.
+// Slices and maps of structs only include the elements which container already has.
+func (l *Loader) Describe(container interface{}, prefix string) ([]Description, error) {
+	var descriptions []Description
+	secrets := l.secretFields(container, prefix)
+	err := visit(container, prefix, visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			description := Description{
+				Key:      environment,
+				Type:     value.Type().String(),
+				Required: field.required,
+				Secret:   l.isSecret(environment, field, secrets),
+				Desc:     field.description,
+			}
+			if !description.Secret {
+				if !value.IsZero() {
+					description.Default = l.display(field, value)
+				} else if field.hasDefault {
+					description.Default = field.defaultValue
+				}
+			}
+			descriptions = append(descriptions, description)
+			return nil
+		},
+		naming: l.naming,
+	})
+	return descriptions, err
+}
+
+// WriteMarkdown writes the descriptions to w as a Markdown table.
+func WriteMarkdown(w io.Writer, descriptions []Description) error {
+	var out strings.Builder
+	out.WriteString("| Key | Type | Default | Required | Secret | Description |\n")
+	out.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, d := range descriptions {
+		fmt.Fprintf(&out, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			d.Key,
+			d.Type,
+			markdownCode(d.Default),
+			yesOrEmpty(d.Required),
+			yesOrEmpty(d.Secret),
+			markdownCell(d.Desc))
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// markdownCode formats value as inline code in a table cell. Empty values stay empty.
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + markdownCell(value) + "`"
+}
+
+// markdownCell escapes the characters which would break a Markdown table row.
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+func yesOrEmpty(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
+
+// WriteEnvExample writes the descriptions to w in the format of a .env file. Each key
+// is set to its default and preceded by comments with its description and type.
+func WriteEnvExample(w io.Writer, descriptions []Description) error {
+	var out strings.Builder
+	for i, d := range descriptions {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		for _, line := range strings.Split(d.Desc, "\n") {
+			if line != "" {
+				out.WriteString("# " + line + "\n")
+			}
+		}
+		out.WriteString("# " + d.Type)
+		if d.Required {
+			out.WriteString(", required")
+		}
+		if d.Secret {
+			out.WriteString(", secret")
+		}
+		out.WriteString("\n")
+		out.WriteString(d.Key + "=" + envValue(d.Default) + "\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// envValue quotes value if it would be misread in a .env file.
+func envValue(value string) string {
+	if strings.ContainsAny(value, " \t\n#\"'\\$") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// WriteJSON writes the descriptions to w as an indented JSON array.
+func WriteJSON(w io.Writer, descriptions []Description) error {
+	if descriptions == nil {
+		descriptions = []Description{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(descriptions)
+}
@@ -0,0 +1,104 @@
+package configs_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type DocsConfig struct {
+	Port     int               `environment:"PORT" desc:"The port to listen on."`
+	Host     string            `environment:"HOST" default:"localhost"`
+	Password string            `environment:"PASSWORD" required:"true" desc:"Used by | clients"`
+	Tags     []string          `environment:"TAGS"`
+	Limits   map[string]int    `environment:"LIMITS"`
+	Greeting string            `environment:"GREETING"`
+	Nested   *Nested           `environment:"NESTED"`
+	Headers  map[string]string `environment:"HEADERS" separator:";"`
+}
+
+func newDocsConfig() *DocsConfig {
+	return &DocsConfig{
+		Port:     8080,
+		Password: "hunter2",
+		Tags:     []string{"a", "b"},
+		Limits:   map[string]int{"paid": 10, "free": 1},
+		Greeting: "hello world",
+		Headers:  map[string]string{"X-A": "1"},
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	descriptions, err := configs.Describe(newDocsConfig(), "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Describe() error: %v", err)
+		return
+	}
+	expected := []configs.Description{
+		{Key: "MY_PORT", Type: "int", Default: "8080", Desc: "The port to listen on."},
+		{Key: "MY_HOST", Type: "string", Default: "localhost"},
+		{Key: "MY_PASSWORD", Type: "string", Required: true, Secret: true, Desc: "Used by | clients"},
+		{Key: "MY_TAGS", Type: "[]string", Default: "a,b"},
+		{Key: "MY_LIMITS", Type: "map[string]int", Default: "free=1,paid=10"},
+		{Key: "MY_GREETING", Type: "string", Default: "hello world"},
+		{Key: "MY_NESTED_VALUE", Type: "int"},
+		{Key: "MY_NESTED_BIG_INT_POINTER", Type: "*big.Int"},
+		{Key: "MY_HEADERS", Type: "map[string]string", Default: "X-A=1"},
+	}
+	assertIntsEqual(t, len(expected), len(descriptions))
+	for i := 0; i < len(expected) && i < len(descriptions); i++ {
+		if descriptions[i] != expected[i] {
+			t.Errorf("Description %d: expected %#v, got %#v", i, expected[i], descriptions[i])
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	descriptions, _ := configs.Describe(newDocsConfig(), "MY")
+	var out strings.Builder
+	if err := configs.WriteMarkdown(&out, descriptions); err != nil {
+		t.Errorf("Got unexpected WriteMarkdown() error: %v", err)
+		return
+	}
+	assertStringContains(t, out.String(), "| Key | Type | Default | Required | Secret | Description |\n")
+	assertStringContains(t, out.String(), "| `MY_PORT` | `int` | `8080` |  |  | The port to listen on. |\n")
+	assertStringContains(t, out.String(), "| `MY_PASSWORD` | `string` |  | yes | yes | Used by \\| clients |\n")
+	assertNotStringContains(t, out.String(), "hunter2")
+}
+
+func TestWriteEnvExample(t *testing.T) {
+	descriptions, _ := configs.Describe(newDocsConfig(), "MY")
+	var out strings.Builder
+	if err := configs.WriteEnvExample(&out, descriptions); err != nil {
+		t.Errorf("Got unexpected WriteEnvExample() error: %v", err)
+		return
+	}
+	assertStringContains(t, out.String(), "# The port to listen on.\n# int\nMY_PORT=8080\n")
+	assertStringContains(t, out.String(), "# string, required, secret\nMY_PASSWORD=\n")
+	assertStringContains(t, out.String(), "MY_GREETING=\"hello world\"\n")
+	assertNotStringContains(t, out.String(), "hunter2")
+}
+
+func TestWriteJSON(t *testing.T) {
+	descriptions, _ := configs.Describe(newDocsConfig(), "MY")
+	var out strings.Builder
+	if err := configs.WriteJSON(&out, descriptions); err != nil {
+		t.Errorf("Got unexpected WriteJSON() error: %v", err)
+		return
+	}
+	var decoded []configs.Description
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Errorf("Got unexpected json.Unmarshal() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, len(descriptions), len(decoded))
+	assertStringContains(t, out.String(), `"description": "The port to listen on."`)
+
+	out.Reset()
+	if err := configs.WriteJSON(&out, nil); err != nil {
+		t.Errorf("Got unexpected WriteJSON() error: %v", err)
+	}
+	assertStringsEqual(t, "[]\n", out.String())
+}
@@ -0,0 +1,99 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change describes a key whose value is different on two configs.
+type Change struct {
+	Key string
+	// Old and New are the values before and after the change, formatted like Dump does.
+	// Secrets are "<redacted>".
+	Old string
+	New string
+	// Added is true if the key is only on the new config, like an element of a slice of
+	// structs which was added. Removed is true if it's only on the old one. Nil pointers
+	// don't have keys either.
+	Added   bool
+	Removed bool
+}
+
+// Diff returns the keys whose values are different on oldContainer and newContainer,
+// sorted by key. Both must be pointers to the same type of struct.
+//
+// Secrets are redacted with the same rules as LogWithPrefix.
+func Diff(oldContainer interface{}, newContainer interface{}, prefix string) ([]Change, error) {
+	return defaultLoader.Diff(oldContainer, newContainer, prefix)
+}
+
+// Diff returns the keys whose values are different on oldContainer and newContainer,
+// sorted by key. Both must be pointers to the same type of struct.
+//
+// Secrets are redacted with the same rules as Log.
+func (l *Loader) Diff(oldContainer interface{}, newContainer interface{}, prefix string) ([]Change, error) {
+	if oldType, newType := reflect.TypeOf(oldContainer), reflect.TypeOf(newContainer); oldType != newType {
+		return nil, fmt.Errorf("configs: can't diff a %v against a %v", oldType, newType)
+	}
+	oldValues, err := l.DumpWithSecrets(oldContainer, prefix)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := l.DumpWithSecrets(newContainer, prefix)
+	if err != nil {
+		return nil, err
+	}
+	// Dump again to find out which values should be hidden.
+	oldRedacted, _ := l.Dump(oldContainer, prefix)
+	newRedacted, _ := l.Dump(newContainer, prefix)
+
+	keys := make(map[string]struct{}, len(oldValues)+len(newValues))
+	for key := range oldValues {
+		keys[key] = struct{}{}
+	}
+	for key := range newValues {
+		keys[key] = struct{}{}
+	}
+	var changes []Change
+	for key := range keys {
+		oldValue, oldSet := oldValues[key]
+		newValue, newSet := newValues[key]
+		if oldSet != newSet || oldValue != newValue {
+			changes = append(changes, Change{
+				Key:     key,
+				Old:     oldRedacted[key],
+				New:     newRedacted[key],
+				Added:   !oldSet,
+				Removed: !newSet,
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// FormatChanges describes the changes with one line each, like:
+//
+//	~ MYAPP_ADMIN_PORT: 81 -> 8081
+//	+ MYAPP_BACKENDS_1_HOST: b.example.com
+//	- MYAPP_DEBUG: true
+//
+// Lines starting with "+" were added, "-" were removed, and "~" were changed.
+func FormatChanges(changes []Change) string {
+	var report strings.Builder
+	for _, change := range changes {
+		switch {
+		case change.Added:
+			fmt.Fprintf(&report, "+ %s: %s\n", change.Key, change.New)
+		case change.Removed:
+			fmt.Fprintf(&report, "- %s: %s\n", change.Key, change.Old)
+		default:
+			fmt.Fprintf(&report, "~ %s: %s -> %s\n", change.Key, change.Old, change.New)
+		}
+	}
+	return report.String()
+}
@@ -0,0 +1,73 @@
+package configs_test
+
+import (
+	"math/big"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+func TestDiff(t *testing.T) {
+	old := Config{
+		Int:          1,
+		SomePassword: "old",
+		Backends:     []Backend{{Host: "a"}},
+		Nested:       &Nested{BigIntPointer: big.NewInt(1)},
+	}
+	new := Config{
+		Int:          2,
+		SomePassword: "new",
+		Nested:       &Nested{},
+		Upstreams: map[string]Upstream{
+			"payments": {URL: "http://payments"},
+		},
+	}
+	changes, err := configs.Diff(&old, &new, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Diff() error: %v", err)
+		return
+	}
+	expected := []configs.Change{
+		{Key: "MY_BACKENDS_0_HOST", Old: "a", Removed: true},
+		{Key: "MY_BACKENDS_0_PORT", Old: "0", Removed: true},
+		{Key: "MY_BACKENDS_0_WEIGHT", Old: "0", Removed: true},
+		{Key: "MY_INT", Old: "1", New: "2"},
+		{Key: "MY_NESTED_BIG_INT_POINTER", Old: "1", Removed: true},
+		{Key: "MY_SOME_PASSWORD", Old: "<redacted>", New: "<redacted>"},
+		{Key: "MY_UPSTREAMS_payments_TIMEOUT_SECONDS", New: "0", Added: true},
+		{Key: "MY_UPSTREAMS_payments_URL", New: "http://payments", Added: true},
+	}
+	assertIntsEqual(t, len(expected), len(changes))
+	for i := 0; i < len(expected) && i < len(changes); i++ {
+		if expected[i] != changes[i] {
+			t.Errorf("Change %d: expected %#v, got %#v", i, expected[i], changes[i])
+		}
+	}
+
+	report := configs.FormatChanges(changes)
+	assertStringContains(t, report, "- MY_BACKENDS_0_HOST: a\n")
+	assertStringContains(t, report, "~ MY_INT: 1 -> 2\n")
+	assertStringContains(t, report, "~ MY_SOME_PASSWORD: <redacted> -> <redacted>\n")
+	assertStringContains(t, report, "+ MY_UPSTREAMS_payments_URL: http://payments\n")
+	assertNotStringContains(t, report, "old")
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	cfg := newDumpedConfig()
+	changes, err := configs.Diff(&cfg, &cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Diff() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 0, len(changes))
+	assertStringsEqual(t, "", configs.FormatChanges(changes))
+}
+
+func TestDiffDifferentTypes(t *testing.T) {
+	_, err := configs.Diff(&Config{}, &Nested{}, "MY")
+	if err == nil {
+		t.Error("Missing expected Diff() error")
+		return
+	}
+	assertStringsEqual(t, "configs: can't diff a *configs_test.Config against a *configs_test.Nested", err.Error())
+}
@@ -0,0 +1,74 @@
+package configs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Dump returns the keys and values on container, in the form that LoadWithPrefix reads them.
+// Secrets are replaced with "<redacted>". See DumpWithSecrets.
+func Dump(container interface{}, prefix string) (map[string]string, error) {
+	return defaultLoader.Dump(container, prefix)
+}
+
+// DumpWithSecrets works like Dump, but includes the values of secrets.
+func DumpWithSecrets(container interface{}, prefix string) (map[string]string, error) {
+	return defaultLoader.DumpWithSecrets(container, prefix)
+}
+
+// Dump returns the keys and values on container, in the form that Load reads them.
+// Loading the result with a MapSource gives back an equal struct, except that empty slices
+// and maps come back as nil, and elements which contain a separator get split up.
+//
+// Secrets are replaced with "<redacted>", including the ones inside JSON values, and nil
+// pointers are left out.
+func (l *Loader) Dump(container interface{}, prefix string) (map[string]string, error) {
+	return l.dump(container, prefix, false)
+}
+
+// DumpWithSecrets works like Dump, but includes the values of secrets.
+// This is meant for passing config to a child process, so be careful where the result goes.
+func (l *Loader) DumpWithSecrets(container interface{}, prefix string) (map[string]string, error) {
+	return l.dump(container, prefix, true)
+}
+
+func (l *Loader) dump(container interface{}, prefix string, withSecrets bool) (map[string]string, error) {
+	dumped := make(map[string]string)
+	secrets := l.secretFields(container, prefix)
+	err := visit(container, prefix, visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				return nil
+			}
+			switch {
+			case withSecrets:
+				dumped[environment] = l.format(field, value)
+			case l.isSecret(environment, field, secrets):
+				dumped[environment] = "<redacted>"
+			default:
+				dumped[environment] = l.display(field, value)
+			}
+			return nil
+		},
+		naming: l.naming,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dumped, nil
+}
+
+// Environ formats the result of Dump as "KEY=value" lines, sorted by key.
+// This is the same format as os.Environ, so it can be used as an exec.Cmd's Env.
+func Environ(dumped map[string]string) []string {
+	keys := make([]string, 0, len(dumped))
+	for key := range dumped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + dumped[key]
+	}
+	return lines
+}
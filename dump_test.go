@@ -0,0 +1,122 @@
+package configs_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+func newDumpedConfig() Config {
+	return Config{
+		Boolean:     true,
+		Int:         -10,
+		UINT_8:      6,
+		UINT_64:     1 << 63,
+		BigInt:      *big.NewInt(-9571),
+		String:      "some string",
+		IntSlice:    []int{1, -2},
+		StringSlice: []string{"abc", "def"},
+		StringMap:   map[string]string{"a": "b", "c": "d=e"},
+		IntMap:      map[string]int{"a": 1},
+		UintBoolMap: map[uint8]bool{1: true, 2: false},
+		Nested: &Nested{
+			Value:         20,
+			BigIntPointer: big.NewInt(112),
+		},
+		Backends: []Backend{
+			{Host: "a", Port: 1},
+			{Host: "b", Port: 2, Weight: 3},
+		},
+		Upstreams: map[string]Upstream{
+			"payments": {URL: "http://payments", TimeoutSeconds: 5},
+		},
+		SomePassword: "secret",
+	}
+}
+
+func TestDump(t *testing.T) {
+	cfg := newDumpedConfig()
+	dumped, err := configs.Dump(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Dump() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "true", dumped["MY_BOOLEAN"])
+	assertStringsEqual(t, "-10", dumped["MY_INT"])
+	assertStringsEqual(t, "9223372036854775808", dumped["MY_UINT_64"])
+	assertStringsEqual(t, "-9571", dumped["MY_BIG_INT"])
+	assertStringsEqual(t, "1,-2", dumped["MY_INT_SLICE"])
+	assertStringsEqual(t, "a=b,c=d=e", dumped["MY_STRING_MAP"])
+	assertStringsEqual(t, "1:true;2:false", dumped["MY_UINT_BOOL_MAP"])
+	assertStringsEqual(t, "112", dumped["MY_NESTED_BIG_INT_POINTER"])
+	assertStringsEqual(t, "b", dumped["MY_BACKENDS_1_HOST"])
+	assertStringsEqual(t, "http://payments", dumped["MY_UPSTREAMS_payments_URL"])
+	assertStringsEqual(t, "<redacted>", dumped["MY_SOME_PASSWORD"])
+
+	withSecrets, err := configs.DumpWithSecrets(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected DumpWithSecrets() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "secret", withSecrets["MY_SOME_PASSWORD"])
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	original := newDumpedConfig()
+	dumped, err := configs.DumpWithSecrets(&original, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected DumpWithSecrets() error: %v", err)
+		return
+	}
+	var loaded Config
+	if err := configs.NewLoader(configs.WithSource(configs.MapSource(dumped))).Load(&loaded, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(original, loaded) {
+		t.Errorf("Loading the dumped values gave a different struct.\nExpected: %#v\nGot:      %#v", original, loaded)
+	}
+}
+
+func TestDurations(t *testing.T) {
+	type Timeouts struct {
+		Read  time.Duration `environment:"READ"`
+		Write time.Duration `environment:"WRITE"`
+	}
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_READ":  "1m30s",
+		"MY_WRITE": "soon",
+	})))
+	var cfg Timeouts
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `MY_WRITE must be a duration like 1m30s or 250ms: got "soon"`)
+
+	loader = configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_READ": "1m30s",
+	})))
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, int(90*time.Second), int(cfg.Read))
+
+	dumped, _ := configs.Dump(&cfg, "MY")
+	assertStringsEqual(t, "1m30s", dumped["MY_READ"])
+	assertStringsEqual(t, "0s", dumped["MY_WRITE"])
+}
+
+func TestEnviron(t *testing.T) {
+	lines := configs.Environ(map[string]string{
+		"MY_B":   "2",
+		"MY_A_B": "3",
+		"MY_A":   "1=1",
+	})
+	assertStringSlicesEqual(t, []string{"MY_A=1=1", "MY_A_B=3", "MY_B=2"}, lines)
+}
@@ -0,0 +1,100 @@
+package configs
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// byteEncoding decodes the values of []byte fields, and encodes them again.
+// Fields choose one with the "encoding" tag.
+type byteEncoding struct {
+	decode func(value string) ([]byte, error)
+	encode func(value []byte) string
+}
+
+// defaultByteEncoding is used by []byte fields without an "encoding" tag.
+const defaultByteEncoding = "base64"
+
+var byteEncodings = map[string]byteEncoding{
+	"base64":       base64Encoding(base64.StdEncoding, "base64"),
+	"base64url":    base64Encoding(base64.URLEncoding, "URL-safe base64"),
+	"rawbase64":    base64Encoding(base64.RawStdEncoding, "base64 without padding"),
+	"rawbase64url": base64Encoding(base64.RawURLEncoding, "URL-safe base64 without padding"),
+	"hex": {
+		decode: decodeHex,
+		encode: hex.EncodeToString,
+	},
+}
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// isBytes returns true if theType is a []byte, or a named type like one.
+// net.IP doesn't count, because it's loaded from an address instead.
+func isBytes(theType reflect.Type) bool {
+	return theType.Kind() == reflect.Slice && theType.Elem().Kind() == reflect.Uint8 && theType != ipType
+}
+
+// byteEncodingNames lists the values which the "encoding" tag accepts, for error messages.
+func byteEncodingNames() string {
+	names := make([]string, 0, len(byteEncodings))
+	for name := range byteEncodings {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func base64Encoding(encoding *base64.Encoding, description string) byteEncoding {
+	return byteEncoding{
+		decode: func(value string) ([]byte, error) {
+			decoded, err := encoding.DecodeString(value)
+			var corrupt base64.CorruptInputError
+			if errors.As(err, &corrupt) {
+				return nil, fmt.Errorf("must be %s, but has invalid data at byte %d", description, int64(corrupt))
+			}
+			return decoded, err
+		},
+		encode: encoding.EncodeToString,
+	}
+}
+
+// decodeHex works like hex.DecodeString, but its errors don't include any of the value,
+// which is probably a secret.
+func decodeHex(value string) ([]byte, error) {
+	invalid := strings.IndexFunc(value, func(r rune) bool {
+		return !('0' <= r && r <= '9') && !('a' <= r && r <= 'f') && !('A' <= r && r <= 'F')
+	})
+	if invalid >= 0 {
+		return nil, fmt.Errorf("must be hex, but has an invalid character at byte %d", invalid)
+	}
+	if len(value)%2 == 1 {
+		return nil, errors.New("must be hex, but has an odd number of digits")
+	}
+	return hex.DecodeString(value)
+}
+
+// parseAndSetBytes decodes value into toSet, which must be a []byte.
+// An empty value sets nil, like it does for other slices.
+func parseAndSetBytes(toSet reflect.Value, value string, encoding string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		toSet.Set(reflect.Zero(toSet.Type()))
+		return nil
+	}
+	decoded, err := byteEncodings[orDefault(encoding, defaultByteEncoding)].decode(value)
+	if err != nil {
+		return err
+	}
+	toSet.Set(reflect.ValueOf(decoded).Convert(toSet.Type()))
+	return nil
+}
+
+// formatBytes encodes value, which must be a []byte, so that parseAndSetBytes would accept it.
+func formatBytes(value reflect.Value, encoding string) string {
+	return byteEncodings[orDefault(encoding, defaultByteEncoding)].encode(value.Convert(bytesType).Interface().([]byte))
+}
@@ -0,0 +1,133 @@
+package configs_test
+
+import (
+	"bytes"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type KeysConfig struct {
+	HMAC      []byte `environment:"HMAC"`
+	URLSafe   []byte `environment:"URL_SAFE" encoding:"base64url"`
+	Raw       []byte `environment:"RAW" encoding:"rawbase64"`
+	RawURL    []byte `environment:"RAW_URL" encoding:"rawbase64url"`
+	Hex       []byte `environment:"HEX" encoding:"hex"`
+	PublicKey []byte `environment:"PUBLIC_KEY" encoding:"hex" secret:"false"`
+}
+
+func TestByteEncodings(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_HMAC":       "+/8A",
+		"MY_URL_SAFE":   "-_8A",
+		"MY_RAW":        "+/8",
+		"MY_RAW_URL":    "-_8",
+		"MY_HEX":        "fbFF00",
+		"MY_PUBLIC_KEY": "",
+	})))
+	cfg := KeysConfig{
+		PublicKey: []byte{1},
+	}
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertBytesEqual(t, []byte{0xfb, 0xff, 0x00}, cfg.HMAC)
+	assertBytesEqual(t, []byte{0xfb, 0xff, 0x00}, cfg.URLSafe)
+	assertBytesEqual(t, []byte{0xfb, 0xff}, cfg.Raw)
+	assertBytesEqual(t, []byte{0xfb, 0xff}, cfg.RawURL)
+	assertBytesEqual(t, []byte{0xfb, 0xff, 0x00}, cfg.Hex)
+	if cfg.PublicKey != nil {
+		t.Errorf("Expected an empty value to set nil. Got %v", cfg.PublicKey)
+	}
+}
+
+func TestBadByteEncodings(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_HMAC":       "c2VjcmV0=",
+		"MY_URL_SAFE":   "c2Vj+mV0",
+		"MY_RAW":        "c2VjcmV0==",
+		"MY_HEX":        "5ecre7",
+		"MY_PUBLIC_KEY": "abc",
+	})))
+	var cfg KeysConfig
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_HMAC must be base64, but has invalid data at byte 8\n")
+	assertStringContains(t, msg, "MY_URL_SAFE must be URL-safe base64, but has invalid data at byte 4\n")
+	assertStringContains(t, msg, "MY_RAW must be base64 without padding, but has invalid data at byte 8\n")
+	assertStringContains(t, msg, "MY_HEX must be hex, but has an invalid character at byte 3\n")
+	assertStringContains(t, msg, `MY_PUBLIC_KEY must be hex, but has an odd number of digits: got "abc"`)
+	assertNotStringContains(t, msg, "c2Vj")
+	assertNotStringContains(t, msg, "5ecre7")
+}
+
+func TestEnsureHidesBytes(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_HMAC":       "c2VjcmV0c2VjcmV0",
+		"MY_PUBLIC_KEY": "abcd",
+	})))
+	var cfg KeysConfig
+	err := loader.Load(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	err = loader.Ensure(err, &cfg, "MY", "MY_HMAC", len(cfg.HMAC) >= 32, "must be at least 32 bytes")
+	err = loader.Ensure(err, &cfg, "MY", "MY_PUBLIC_KEY", len(cfg.PublicKey) >= 32, "must be at least 32 bytes")
+	if err == nil {
+		t.Error("Ensure() should have returned a real error")
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_HMAC must be at least 32 bytes\n")
+	assertStringContains(t, err.Error(), `MY_PUBLIC_KEY must be at least 32 bytes: got "abcd"`)
+	assertNotStringContains(t, err.Error(), "c2VjcmV0c2VjcmV0")
+}
+
+func TestLogBytes(t *testing.T) {
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(configs.WithLogger(logger))
+	cfg := KeysConfig{
+		HMAC:      []byte("secret"),
+		PublicKey: []byte{0xab, 0xcd},
+	}
+	loader.Log(&cfg, "MY")
+	logged := logger.String()
+	assertStringContains(t, logged, "MY_HMAC: <redacted>")
+	assertStringContains(t, logged, "MY_PUBLIC_KEY: abcd")
+	assertNotStringContains(t, logged, "c2VjcmV0")
+
+	dumped, err := configs.DumpWithSecrets(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected DumpWithSecrets() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "c2VjcmV0", dumped["MY_HMAC"])
+	assertStringsEqual(t, "abcd", dumped["MY_PUBLIC_KEY"])
+}
+
+func TestBadEncodingTags(t *testing.T) {
+	type BadEncodings struct {
+		Unknown  []byte `environment:"UNKNOWN" encoding:"base32"`
+		NotBytes string `environment:"NOT_BYTES" encoding:"hex"`
+	}
+	err := configs.NewLoader().Load(&BadEncodings{}, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `BadEncodings.Unknown has encoding:"base32", but the encoding must be one of "base64", "base64url", "hex", "rawbase64", "rawbase64url"`)
+	assertStringContains(t, msg, "BadEncodings.NotBytes has an encoding tag, but only []byte fields can have one")
+}
+
+func assertBytesEqual(t *testing.T, expected []byte, actual []byte) {
+	t.Helper()
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("Expected %v. Got %v", expected, actual)
+	}
+}
@@ -0,0 +1,155 @@
+package configs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReadEnv parses the contents of a .env file into a map, which can be used with MapSource.
+//
+// Blank lines and lines starting with "#" are ignored, and keys may start with "export ".
+// Values in double quotes are unquoted like Go strings, and values in single quotes are
+// used as-is. Unquoted values end at the first " #", so that lines can have comments.
+func ReadEnv(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		equals := strings.Index(line, "=")
+		if equals <= 0 {
+			return nil, fmt.Errorf("line %d must look like KEY=value", lineNumber)
+		}
+		key := strings.TrimSpace(line[:equals])
+		value, err := parseEnvValue(strings.TrimSpace(line[equals+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d has an invalid value: %v", lineNumber, err)
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// FileSource is a Source backed by .env files. Its values are read once, and again
+// whenever Reload is called.
+type FileSource struct {
+	paths []string
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// EnvFile returns a Source with the values in the .env files at paths. If a key is
+// in more than one of them, the last file wins.
+func EnvFile(paths ...string) (*FileSource, error) {
+	src := &FileSource{paths: paths}
+	if err := src.Reload(); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// Lookup returns the value of key, and whether or not it was set.
+func (f *FileSource) Lookup(key string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	value, ok := f.values[key]
+	return value, ok
+}
+
+// Keys returns every key which has a value in the files.
+func (f *FileSource) Keys() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	keys := make([]string, 0, len(f.values))
+	for key := range f.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Files returns the paths of the files which the values are read from.
+func (f *FileSource) Files() []string {
+	return append([]string(nil), f.paths...)
+}
+
+// Reload reads the files again. If any of them can't be read, the old values are kept.
+func (f *FileSource) Reload() error {
+	values := make(map[string]string)
+	for _, path := range f.paths {
+		fileValues, err := readEnvFile(path)
+		if err != nil {
+			return err
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+	f.mu.Lock()
+	f.values = values
+	f.mu.Unlock()
+	return nil
+}
+
+func readEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	values, err := ReadEnv(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
+
+// errMissingQuote doesn't include the value, because it might be a secret.
+var errMissingQuote = errors.New("missing closing quote")
+
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", errMissingQuote
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errMissingQuote
+		}
+		return value[1 : end+1], nil
+	default:
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		return value, nil
+	}
+}
+
+// closingQuote returns the index of the double quote which ends value,
+// or -1 if there isn't one.
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,54 @@
+package configs_test
+
+import (
+	"strings"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+func TestParseEnv(t *testing.T) {
+	values, err := configs.ReadEnv(strings.NewReader(`
+# A comment
+PLAIN=value
+export EXPORTED=1
+COMMENTED=value # trailing comment
+DOUBLE="hello \"world\"\n"
+SINGLE='it has # and \n'
+EMPTY=
+`))
+	if err != nil {
+		t.Fatalf("Got unexpected configs.ReadEnv() error: %v", err)
+	}
+	expected := map[string]string{
+		"PLAIN":     "value",
+		"EXPORTED":  "1",
+		"COMMENTED": "value",
+		"DOUBLE":    "hello \"world\"\n",
+		"SINGLE":    `it has # and \n`,
+		"EMPTY":     "",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values, but got %d: %v", len(expected), len(values), values)
+	}
+	for key, value := range expected {
+		if values[key] != value {
+			t.Errorf("Expected %s to be %q, but got %q", key, value, values[key])
+		}
+	}
+}
+
+func TestParseEnvErrors(t *testing.T) {
+	inputs := map[string]string{
+		"NO_EQUALS":         "line 1 must look like KEY=value",
+		"=value":            "line 1 must look like KEY=value",
+		"A=1\nB=\"unended":  "line 2 has an invalid value: missing closing quote",
+		"PASSWORD='hunter2": "line 1 has an invalid value: missing closing quote",
+	}
+	for input, expected := range inputs {
+		_, err := configs.ReadEnv(strings.NewReader(input))
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected error %q for %q, but got %v", expected, input, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expander expands references like ${KEY} in values from a Source.
+//
+// The syntax is a small part of the shell's:
+//
+//	${KEY}          the value of KEY, which must be set
+//	${KEY:-default} the value of KEY, or default if KEY is unset or empty
+//	$$              a literal "$"
+//
+// Referenced values are expanded too, so "${A}" can refer to a value like "${B}/path".
+// A "$" which isn't followed by "{" or "$" is left as-is.
+type expander struct {
+	source Source
+	// stack holds the keys whose values are being expanded, to catch cycles.
+	stack []string
+	// referenced holds every key which was referenced, directly or indirectly.
+	referenced map[string]struct{}
+}
+
+// expandValue expands the references in value, which was loaded from key.
+// It returns the keys which were referenced along with the expanded value.
+func expandValue(src Source, key string, value string) (string, map[string]struct{}, error) {
+	e := expander{
+		source:     src,
+		stack:      []string{key},
+		referenced: make(map[string]struct{}),
+	}
+	expanded, err := e.expand(value, 0)
+	return expanded, e.referenced, err
+}
+
+// expand expands the references in value, which starts at offset in the value of the
+// key being expanded. Offsets are only used in errors.
+func (e *expander) expand(value string, offset int) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			out.WriteByte(value[i])
+			continue
+		}
+		switch value[i+1] {
+		case '$':
+			out.WriteByte('$')
+			i++
+		case '{':
+			end := closingBrace(value, i+2)
+			if end < 0 {
+				return "", e.errorAt(offset+i, "has an unterminated reference")
+			}
+			expanded, err := e.expandReference(value[i+2:end], offset+i+2)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(expanded)
+			i = end
+		default:
+			out.WriteByte('$')
+		}
+	}
+	return out.String(), nil
+}
+
+// expandReference expands the inside of a reference, like the "KEY:-default" in "${KEY:-default}".
+// It starts at offset in the value of the key being expanded.
+func (e *expander) expandReference(reference string, offset int) (string, error) {
+	key, fallback, hasFallback := strings.Cut(reference, ":-")
+	if key == "" {
+		return "", e.errorAt(offset-2, "has a reference without a key")
+	}
+	for i, expanding := range e.stack {
+		if expanding == key {
+			cycle := append(append([]string(nil), e.stack[i:]...), key)
+			return "", fmt.Errorf("has a reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	e.referenced[key] = struct{}{}
+	value, isSet := e.source.Lookup(key)
+	if hasFallback && value == "" {
+		return e.expand(fallback, offset+len(key)+len(":-"))
+	}
+	if !isSet {
+		return "", fmt.Errorf("references ${%s}, which isn't set", key)
+	}
+	e.stack = append(e.stack, key)
+	defer func() {
+		e.stack = e.stack[:len(e.stack)-1]
+	}()
+	return e.expand(value, 0)
+}
+
+// errorAt describes a problem at offset in the value of the key being expanded.
+// The value itself is left out, because it might be a secret.
+func (e *expander) errorAt(offset int, problem string) error {
+	if len(e.stack) > 1 {
+		return fmt.Errorf("%s at byte %d of %s's value", problem, offset, e.stack[len(e.stack)-1])
+	}
+	return fmt.Errorf("%s at byte %d", problem, offset)
+}
+
+// closingBrace returns the index of the "}" which closes a reference whose contents start
+// at value[start], or -1 if there isn't one. References inside of it are skipped over.
+func closingBrace(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch {
+		case value[i] == '$' && i+1 < len(value) && value[i+1] == '{':
+			depth++
+			i++
+		case value[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// referencesSecret returns true if the value of key in the Loader's source refers to any of
+// the secrets, directly or by way of other references. This keeps secrets from being printed
+// by way of an expanded value.
+//
+// Values are checked even if the Loader doesn't expand them, because the struct may have
+// been loaded by one which does.
+func (l *Loader) referencesSecret(key string, field *fieldPlan, secrets map[string]struct{}) bool {
+	value, isSet := l.source.Lookup(key)
+	for _, fieldAlias := range field.aliases {
+		if isSet {
+			break
+		}
+		value, isSet = l.source.Lookup(l.naming.aliasKey(key, field, fieldAlias))
+	}
+	if !isSet || !strings.Contains(value, "${") {
+		return false
+	}
+	_, referenced, _ := expandValue(l.source, key, value)
+	return l.anySecret(referenced, secrets)
+}
+
+// anySecret returns true if any of the referenced keys are in secrets, or look like secrets.
+func (l *Loader) anySecret(referenced map[string]struct{}, secrets map[string]struct{}) bool {
+	for reference := range referenced {
+		_, isSecret := secrets[reference]
+		if isSecret || matchesRedactions(reference, l.redactions) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package configs_test
+
+import (
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type ExpandedConfig struct {
+	Host      string `environment:"HOST"`
+	Port      int    `environment:"PORT"`
+	PublicURL string `environment:"PUBLIC_URL"`
+	Price     string `environment:"PRICE"`
+}
+
+func TestExpandVariables(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_HOST":       "${MY_DOMAIN}",
+			"MY_DOMAIN":     "example.com",
+			"MY_PORT":       "${MY_PORT_NUMBER:-8443}",
+			"MY_PUBLIC_URL": "https://${MY_HOST}:${MY_PORT}/${MY_PATH:-}",
+			"MY_PRICE":      "$$5 or $5",
+		})),
+		configs.ExpandVariables(),
+	)
+	var cfg ExpandedConfig
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "example.com", cfg.Host)
+	assertIntsEqual(t, 8443, cfg.Port)
+	assertStringsEqual(t, "https://example.com:8443/", cfg.PublicURL)
+	assertStringsEqual(t, "$5 or $5", cfg.Price)
+}
+
+func TestExpandTag(t *testing.T) {
+	type Tagged struct {
+		Expanded   string `environment:"EXPANDED" expand:"true"`
+		Unexpanded string `environment:"UNEXPANDED"`
+	}
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_EXPANDED":   "${MY_UNEXPANDED}!",
+		"MY_UNEXPANDED": "$${HOME}",
+	})))
+	var cfg Tagged
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "$${HOME}", cfg.Unexpanded)
+	assertStringsEqual(t, "${HOME}!", cfg.Expanded)
+}
+
+func TestExpansionErrors(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_HOST":       "${MY_PUBLIC_URL}",
+			"MY_PUBLIC_URL": "https://${MY_HOST}",
+			"MY_PORT":       "${MY_MISSING}",
+			"MY_PRICE":      "${MY_HOST",
+		})),
+		configs.ExpandVariables(),
+	)
+	var cfg ExpandedConfig
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Error("Missing expected Load() error")
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_HOST has a reference cycle: MY_HOST -> MY_PUBLIC_URL -> MY_HOST")
+	assertStringContains(t, err.Error(), "MY_PUBLIC_URL has a reference cycle: MY_PUBLIC_URL -> MY_HOST -> MY_PUBLIC_URL")
+	assertStringContains(t, err.Error(), "MY_PORT references ${MY_MISSING}, which isn't set")
+	assertStringContains(t, err.Error(), `MY_PRICE has an unterminated reference at byte 0: got "${MY_HOST"`)
+}
+
+func TestMalformedReferencesHideValues(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_PASSWORD":   "hunter2${tail-of-secret",
+			"MY_KEY":        "abc${:-zzsecret}",
+			"MY_PUBLIC_URL": "https://${MY_HOST:-${MY_TOKEN}}",
+			"MY_TOKEN":      "x${y",
+		})),
+		configs.ExpandVariables(),
+	)
+	var cfg struct {
+		Password  string `environment:"PASSWORD"`
+		Key       []byte `environment:"KEY"`
+		PublicURL string `environment:"PUBLIC_URL"`
+	}
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Error("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_PASSWORD has an unterminated reference at byte 7\n")
+	assertStringContains(t, msg, "MY_KEY has a reference without a key at byte 3\n")
+	assertStringContains(t, msg, "MY_PUBLIC_URL has an unterminated reference at byte 1 of MY_TOKEN's value")
+	assertNotStringContains(t, msg, "hunter2")
+	assertNotStringContains(t, msg, "tail-of-secret")
+	assertNotStringContains(t, msg, "zzsecret")
+	assertNotStringContains(t, msg, "x${y")
+}
+
+func TestExpandedSecretsStayHidden(t *testing.T) {
+	type WithSecrets struct {
+		DatabaseURL string `environment:"DATABASE_URL"`
+		Token       string `environment:"TOKEN" secret:"true"`
+		Header      string `environment:"HEADER"`
+		Port        int    `environment:"PORT"`
+	}
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_DB_PASSWORD":  "hunter2",
+			"MY_DATABASE_URL": "postgres://me:${MY_DB_PASSWORD}@db",
+			"MY_TOKEN":        "abc123",
+			"MY_HEADER":       "Bearer ${MY_TOKEN}",
+			"MY_PORT":         "${MY_DB_PASSWORD}",
+		})),
+		configs.WithLogger(logger),
+		configs.ExpandVariables(),
+	)
+	var cfg WithSecrets
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Error("Missing expected Load() error")
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_PORT must be an int\n")
+	assertNotStringContains(t, err.Error(), "hunter2")
+
+	loader = configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_DB_PASSWORD":  "hunter2",
+			"MY_DATABASE_URL": "postgres://me:${MY_DB_PASSWORD}@db",
+			"MY_TOKEN":        "abc123",
+			"MY_HEADER":       "Bearer ${MY_TOKEN}",
+		})),
+		configs.WithLogger(logger),
+		configs.ExpandVariables(),
+	)
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "Bearer abc123", cfg.Header)
+	loader.Log(&cfg, "MY")
+	assertStringContains(t, logger.String(), "MY_DATABASE_URL: <redacted>")
+	assertStringContains(t, logger.String(), "MY_HEADER: <redacted>")
+	assertNotStringContains(t, logger.String(), "hunter2")
+	assertNotStringContains(t, logger.String(), "abc123")
+}
+
+func TestExpandedSecretsStayHiddenAfterLoading(t *testing.T) {
+	defer setEnv(t, "MY_DB_PASSWORD", "hunter2")()
+	defer setEnv(t, "MY_URL", "x://${MY_DB_PASSWORD}@h")()
+	var cfg struct {
+		URL string `environment:"URL"`
+	}
+	loader := configs.NewLoader(configs.ExpandVariables())
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "x://hunter2@h", cfg.URL)
+
+	// None of these have loaded anything, so they have to work it out from the source.
+	logger := &bufferLogger{}
+	configs.NewLoader(configs.WithLogger(logger)).Log(&cfg, "MY")
+	assertStringContains(t, logger.String(), "MY_URL: <redacted>")
+
+	descriptions, err := configs.Describe(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Describe() error: %v", err)
+		return
+	}
+	if !descriptions[0].Secret || descriptions[0].Default != "" {
+		t.Errorf("Expected MY_URL to be described as a secret. Got %#v", descriptions[0])
+	}
+
+	dumped, err := configs.Dump(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Dump() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "<redacted>", dumped["MY_URL"])
+
+	err = loader.Ensure(nil, &cfg, "MY", "MY_URL", false, "is invalid")
+	assertStringContains(t, err.Error(), "MY_URL is invalid\n")
+	assertNotStringContains(t, err.Error(), "hunter2")
+}
@@ -0,0 +1,97 @@
+package configs
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// format turns a field's value back into a string which parseAndSet would accept.
+// It's the opposite of parseAndSet, and uses the same separators and base.
+func (l *Loader) format(field *fieldPlan, value reflect.Value) string {
+	return l.formatValue(field, value, false)
+}
+
+// display works like format, but hides the secrets inside JSON values and URLs.
+// It's for values which get printed, rather than loaded again.
+func (l *Loader) display(field *fieldPlan, value reflect.Value) string {
+	if field.format == jsonFormat {
+		return l.redactedJSON(value)
+	}
+	return l.formatValue(field, value, true)
+}
+
+// formatValue implements format and display. If redact is true, passwords in URLs are hidden.
+func (l *Loader) formatValue(field *fieldPlan, value reflect.Value, redact bool) string {
+	if field.format == jsonFormat {
+		return formatJSON(value)
+	}
+	base := l.parseOptionsFor(field).base
+	if isNetworkType(value.Type()) {
+		// This catches net.IP, which is a slice but holds a single value.
+		return formatNetwork(value, redact)
+	}
+	switch value.Kind() {
+	case reflect.Slice:
+		if isBytes(value.Type()) {
+			return formatBytes(value, field.encoding)
+		}
+		parts := make([]string, value.Len())
+		for i := range parts {
+			parts[i] = formatScalar(value.Index(i), base, redact)
+		}
+		return strings.Join(parts, orDefault(field.separator, l.separator))
+	case reflect.Map:
+		kvSeparator := orDefault(field.kvSeparator, l.kvSeparator)
+		pairs := make([]string, 0, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, formatScalar(iter.Key(), base, redact)+kvSeparator+formatScalar(iter.Value(), base, redact))
+		}
+		// Map iteration is random, so sort the pairs to make the output stable.
+		sort.Strings(pairs)
+		return strings.Join(pairs, orDefault(field.separator, l.separator))
+	default:
+		return formatScalar(value, base, redact)
+	}
+}
+
+// formatScalar turns a single value into a string which parseAndSetScalar would accept.
+func formatScalar(value reflect.Value, base int, redact bool) string {
+	if base == 0 {
+		base = 10
+	}
+	if isNetworkType(value.Type()) {
+		return formatNetwork(value, redact)
+	}
+	switch value.Type() {
+	case byteSizeType, percentType, durationType:
+		return value.Interface().(fmt.Stringer).String()
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), base)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), base)
+	case reflect.String:
+		return value.String()
+	case reflect.Struct:
+		if value.Type() == bigIntType {
+			copied := reflect.New(bigIntType)
+			copied.Elem().Set(value)
+			return copied.Interface().(*big.Int).Text(base)
+		}
+	case reflect.Ptr:
+		if value.IsNil() {
+			return ""
+		}
+		return formatScalar(value.Elem(), base, redact)
+	}
+	return fmt.Sprintf("%v", value)
+}
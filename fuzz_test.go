@@ -0,0 +1,258 @@
+package configs_test
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+// ScalarConfig has one of each type which can be dumped and loaded again exactly.
+type ScalarConfig struct {
+	Bool     bool             `environment:"BOOL"`
+	Int      int              `environment:"INT"`
+	Hex      int              `environment:"HEX" base:"16"`
+	Uint8    uint8            `environment:"UINT8"`
+	Uint16   uint16           `environment:"UINT16"`
+	Uint32   uint32           `environment:"UINT32"`
+	Uint64   uint64           `environment:"UINT64"`
+	String   string           `environment:"STRING"`
+	BigInt   big.Int          `environment:"BIG_INT"`
+	BigPtr   *big.Int         `environment:"BIG_PTR" base:"0"`
+	Size     configs.ByteSize `environment:"SIZE"`
+	Percent  configs.Percent  `environment:"PERCENT"`
+	Duration time.Duration    `environment:"DURATION"`
+	Ints     []int            `environment:"INTS"`
+	Strings  []string         `environment:"STRINGS" separator:";"`
+	Map      map[string]int   `environment:"MAP"`
+	Bytes    []byte           `environment:"BYTES"`
+	HexBytes []byte           `environment:"HEX_BYTES" encoding:"hex"`
+}
+
+func assertRoundTrips(t *testing.T, original ScalarConfig) {
+	t.Helper()
+	dumped, err := configs.DumpWithSecrets(&original, "MY")
+	if err != nil {
+		t.Fatalf("Got unexpected DumpWithSecrets() error: %v", err)
+	}
+	var loaded ScalarConfig
+	if err := configs.NewLoader(configs.WithSource(configs.MapSource(dumped))).Load(&loaded, "MY"); err != nil {
+		t.Fatalf("Got unexpected Load() error for %v: %v", dumped, err)
+	}
+	// Equal big.Ints can have different internal representations.
+	if !bigIntsEqual(&original.BigInt, &loaded.BigInt) || !bigIntsEqual(original.BigPtr, loaded.BigPtr) {
+		t.Errorf("Loading %v gave different big.Ints. Expected %v and %v, but got %v and %v",
+			dumped, &original.BigInt, original.BigPtr, &loaded.BigInt, loaded.BigPtr)
+	}
+	original.BigInt, original.BigPtr, loaded.BigInt, loaded.BigPtr = big.Int{}, nil, big.Int{}, nil
+	if !reflect.DeepEqual(original, loaded) {
+		t.Errorf("Loading %v gave a different struct.\nExpected: %#v\nGot:      %#v", dumped, original, loaded)
+	}
+}
+
+func bigIntsEqual(a *big.Int, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// randomScalarConfig makes a ScalarConfig whose slices and maps are never empty, because
+// those come back as nil.
+func randomScalarConfig(r *rand.Rand) ScalarConfig {
+	word := func() string {
+		return strconv.FormatUint(r.Uint64(), 36)
+	}
+	return ScalarConfig{
+		Bool:     r.Intn(2) == 0,
+		Int:      int(r.Uint64()),
+		Hex:      int(r.Uint64()),
+		Uint8:    uint8(r.Uint32()),
+		Uint16:   uint16(r.Uint32()),
+		Uint32:   r.Uint32(),
+		Uint64:   r.Uint64(),
+		String:   word() + " " + word(),
+		BigInt:   *new(big.Int).Mul(big.NewInt(r.Int63()), big.NewInt(-r.Int63())),
+		BigPtr:   big.NewInt(r.Int63()),
+		Size:     configs.ByteSize(r.Uint64()),
+		Percent:  configs.Percent(r.NormFloat64() * math.Pow(10, float64(r.Intn(20)-10))),
+		Duration: time.Duration(r.Int63() - r.Int63()),
+		Ints:     []int{int(r.Uint64()), -r.Int()},
+		Strings:  []string{word(), word() + "," + word()},
+		Map:      map[string]int{word(): r.Int(), word(): -r.Int()},
+		Bytes:    []byte(word()),
+		HexBytes: []byte(word()),
+	}
+}
+
+func TestFormatRoundTrips(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		assertRoundTrips(t, randomScalarConfig(r))
+	}
+	assertRoundTrips(t, ScalarConfig{
+		Int:      math.MinInt64,
+		Hex:      math.MaxInt64,
+		Uint64:   math.MaxUint64,
+		Size:     math.MaxUint64,
+		Percent:  math.SmallestNonzeroFloat64,
+		Duration: math.MinInt64,
+		Ints:     []int{math.MinInt64, math.MaxInt64},
+	})
+	assertRoundTrips(t, ScalarConfig{
+		Percent:  math.MaxFloat64,
+		Duration: math.MaxInt64,
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(true, int64(-1), uint64(1), "abc", int64(-5), uint64(1<<20), 0.07, int64(time.Second))
+	f.Add(false, int64(math.MinInt64), uint64(math.MaxUint64), "", int64(0), uint64(0), -1e-300, int64(math.MinInt64))
+	f.Fuzz(func(t *testing.T, b bool, i int64, u uint64, s string, bigInt int64, size uint64, percent float64, duration int64) {
+		if math.IsNaN(percent) || math.IsInf(percent, 0) {
+			t.Skip()
+		}
+		assertRoundTrips(t, ScalarConfig{
+			Bool:     b,
+			Int:      int(i),
+			Hex:      int(i),
+			Uint8:    uint8(u),
+			Uint16:   uint16(u),
+			Uint32:   uint32(u),
+			Uint64:   u,
+			String:   s,
+			BigInt:   *new(big.Int).Mul(big.NewInt(bigInt), big.NewInt(bigInt)),
+			BigPtr:   big.NewInt(bigInt),
+			Size:     configs.ByteSize(size),
+			Percent:  configs.Percent(percent),
+			Duration: time.Duration(duration),
+		})
+	})
+}
+
+// FuzzLoad makes sure that no value makes Load panic, and that every value it accepts
+// can be dumped and loaded again.
+func FuzzLoad(f *testing.F) {
+	for _, seed := range []string{"", "0", "-1", "1e3", "0x1F", "1_000", "12%", "1.5GiB", "1h2m", "a=1,b=2", "${X}", "-99999999999999999999999"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		keys := []string{"BOOL", "INT", "HEX", "UINT8", "UINT16", "UINT32", "UINT64", "STRING", "BIG_INT",
+			"BIG_PTR", "SIZE", "PERCENT", "DURATION", "INTS", "STRINGS", "MAP", "BYTES", "HEX_BYTES"}
+		for _, key := range keys {
+			loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{"MY_" + key: value})))
+			var cfg ScalarConfig
+			if err := loader.Load(&cfg, "MY"); err != nil {
+				continue
+			}
+			if strings.Contains(value, ";") || (key == "MAP" && value == "") {
+				// Strings which contain the separator, and empty maps, don't round trip.
+				continue
+			}
+			assertRoundTrips(t, cfg)
+		}
+	})
+}
+
+// FuzzUint checks that the unsigned parsers agree with strconv, and explain why they don't accept a value.
+func FuzzUint(f *testing.F) {
+	for _, seed := range []string{"0", "255", "256", "-1", "-0", "+1", "18446744073709551616", "-99999999999999999999", "1.5", "x"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{"MY_UINT8": value})))
+		var cfg ScalarConfig
+		err := loader.Load(&cfg, "MY")
+		expected, parseErr := strconv.ParseUint(value, 10, 8)
+		if parseErr == nil {
+			if err != nil || uint64(cfg.Uint8) != expected {
+				t.Errorf("Expected %q to load as %d, but got %d and %v", value, expected, cfg.Uint8, err)
+			}
+			return
+		}
+		if err == nil {
+			t.Fatalf("Expected %q to fail like strconv.ParseUint: %v", value, parseErr)
+		}
+		negative, isInteger := new(big.Int).SetString(value, 10)
+		switch {
+		case parseErr.(*strconv.NumError).Err == strconv.ErrRange:
+			assertStringContains(t, err.Error(), "MY_UINT8 has a max value of 255")
+		case isInteger && negative.Sign() < 0:
+			assertStringContains(t, err.Error(), "MY_UINT8 has a min value of 0")
+		default:
+			assertStringContains(t, err.Error(), "MY_UINT8 must be a uint8")
+		}
+	})
+}
+
+// FuzzInts checks that int slices agree with strconv.
+func FuzzInts(f *testing.F) {
+	for _, seed := range []string{"", "1", "1,-2", "1,,2", "9223372036854775808", " 1", "1,"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{"MY_INTS": value})))
+		var cfg ScalarConfig
+		err := loader.Load(&cfg, "MY")
+		if value == "" {
+			if err != nil || cfg.Ints != nil {
+				t.Errorf("Expected an empty value to load as nil, but got %v and %v", cfg.Ints, err)
+			}
+			return
+		}
+		var expected []int
+		for i, part := range strings.Split(value, ",") {
+			parsed, parseErr := strconv.ParseInt(part, 10, strconv.IntSize)
+			if parseErr != nil {
+				if err == nil {
+					t.Fatalf("Expected %q to fail because of index %d", value, i)
+				}
+				assertStringContains(t, err.Error(), fmt.Sprintf("index %d is invalid", i))
+				return
+			}
+			expected = append(expected, int(parsed))
+		}
+		if err != nil || !reflect.DeepEqual(expected, cfg.Ints) {
+			t.Errorf("Expected %q to load as %v, but got %v and %v", value, expected, cfg.Ints, err)
+		}
+	})
+}
+
+// FuzzBigInt checks that big.Ints agree with big.Int.SetString.
+func FuzzBigInt(f *testing.F) {
+	for _, seed := range []string{"0", "-1", "123456789012345678901234567890", "0x1F", "1_000", "", "+5"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+			"MY_BIG_INT": value,
+			"MY_BIG_PTR": value,
+		})))
+		var cfg ScalarConfig
+		err := loader.Load(&cfg, "MY")
+		base10, ok10 := new(big.Int).SetString(value, 10)
+		base0, ok0 := new(big.Int).SetString(value, 0)
+		if ok10 && ok0 {
+			if err != nil || cfg.BigInt.Cmp(base10) != 0 || cfg.BigPtr.Cmp(base0) != 0 {
+				t.Errorf("Expected %q to load as %v and %v, but got %v, %v and %v", value, base10, base0, &cfg.BigInt, cfg.BigPtr, err)
+			}
+			return
+		}
+		if err == nil {
+			t.Fatalf("Expected %q to fail like big.Int.SetString", value)
+		}
+		if !ok10 {
+			assertStringContains(t, err.Error(), "MY_BIG_INT must be a base-10 big.Int")
+		}
+		if !ok0 {
+			assertStringContains(t, err.Error(), "MY_BIG_PTR must be a big.Int with an optional 0x, 0o or 0b prefix")
+		}
+	})
+}
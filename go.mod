@@ -1,3 +1,3 @@
 module github.com/wikisophia/go-environment-configs
 
-go 1.14
+go 1.18
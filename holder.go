@@ -0,0 +1,35 @@
+package configs
+
+import "sync/atomic"
+
+// Holder holds a config which can be replaced while other goroutines are reading it.
+// Readers get a snapshot with Get, and a reloader replaces the whole config with Set.
+//
+// The zero value holds the zero value of T. Holders must not be copied after first use.
+type Holder[T any] struct {
+	// current holds a *T. It's replaced, never modified.
+	current atomic.Value
+}
+
+// NewHolder returns a Holder which starts out holding cfg.
+func NewHolder[T any](cfg T) *Holder[T] {
+	h := &Holder[T]{}
+	h.Set(cfg)
+	return h
+}
+
+// Get returns the current config. Don't modify any slices, maps or pointers on it,
+// because they're shared with other callers.
+func (h *Holder[T]) Get() T {
+	current, ok := h.current.Load().(*T)
+	if !ok {
+		var zero T
+		return zero
+	}
+	return *current
+}
+
+// Set replaces the config. Calls to Get which start afterwards will return cfg.
+func (h *Holder[T]) Set(cfg T) {
+	h.current.Store(&cfg)
+}
@@ -0,0 +1,33 @@
+package configs_test
+
+import (
+	"sync"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+func TestHolder(t *testing.T) {
+	var empty configs.Holder[WatchedConfig]
+	assertIntsEqual(t, 0, empty.Get().Port)
+
+	holder := configs.NewHolder(WatchedConfig{Host: "initial"})
+	assertStringsEqual(t, "initial", holder.Get().Host)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i == 0 {
+					holder.Set(WatchedConfig{Port: j, Host: "host"})
+				} else if cfg := holder.Get(); cfg.Host != "initial" && cfg.Host != "host" {
+					t.Errorf("Got a torn config: %#v", cfg)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assertIntsEqual(t, 99, holder.Get().Port)
+}
@@ -0,0 +1,178 @@
+package configs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonFormat is the "format" tag which makes a field's value load from JSON.
+const jsonFormat = "json"
+
+// parseAndSetJSON unmarshals value into toSet. The result replaces whatever toSet held before,
+// rather than being merged into it. An empty value sets the zero value, like it does for slices.
+func parseAndSetJSON(toSet reflect.Value, value string) error {
+	parsed := reflect.New(toSet.Type())
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+			return describeJSONError(err)
+		}
+	}
+	toSet.Set(parsed.Elem())
+	return nil
+}
+
+// describeJSONError turns an error from json.Unmarshal into a message which says where the
+// problem is, without repeating any of the value.
+func describeJSONError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("must be valid JSON, but has an error at byte %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr):
+		where := "the value"
+		if typeErr.Field != "" {
+			where = typeErr.Field
+		}
+		return fmt.Errorf("has a JSON %s at byte %d, but %s must be a %v", typeErr.Value, typeErr.Offset, where, typeErr.Type)
+	default:
+		return fmt.Errorf("must be valid JSON: %v", err)
+	}
+}
+
+// formatJSON encodes value as compact JSON, which parseAndSetJSON would accept.
+func formatJSON(value reflect.Value) string {
+	encoded, err := encodeJSON(value.Interface())
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return encoded
+}
+
+// redactedJSON works like formatJSON, but replaces any secrets inside value with "<redacted>".
+//
+// Struct fields tagged with secret:"true" are secrets, as are object keys which match the
+// Loader's redactions, unless their field is tagged with secret:"false".
+func (l *Loader) redactedJSON(value reflect.Value) string {
+	encoded, err := encodeJSON(value.Interface())
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	// Decode into maps and slices, which are easier to redact than arbitrary types.
+	decoder := json.NewDecoder(strings.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	l.redactJSON(decoded, value.Type())
+	redacted, err := encodeJSON(decoded)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return redacted
+}
+
+// redactJSON replaces the secrets in decoded, which was encoded from a value of type theType.
+func (l *Loader) redactJSON(decoded interface{}, theType reflect.Type) {
+	for theType.Kind() == reflect.Ptr {
+		theType = theType.Elem()
+	}
+	switch decoded := decoded.(type) {
+	case map[string]interface{}:
+		switch theType.Kind() {
+		case reflect.Struct:
+			l.redactJSONFields(decoded, theType)
+		case reflect.Map, reflect.Interface:
+			elemType := theType
+			if theType.Kind() == reflect.Map {
+				elemType = theType.Elem()
+			}
+			for key, elem := range decoded {
+				if matchesRedactions(key, l.redactions) {
+					decoded[key] = "<redacted>"
+				} else {
+					l.redactJSON(elem, elemType)
+				}
+			}
+		}
+	case []interface{}:
+		elemType := theType
+		if theType.Kind() == reflect.Slice || theType.Kind() == reflect.Array {
+			elemType = theType.Elem()
+		}
+		for _, elem := range decoded {
+			l.redactJSON(elem, elemType)
+		}
+	}
+}
+
+// redactJSONFields replaces the secrets in an object which was encoded from a struct of type theType.
+func (l *Loader) redactJSONFields(decoded map[string]interface{}, theType reflect.Type) {
+	for i := 0; i < theType.NumField(); i++ {
+		field := theType.Field(i)
+		name, hasName := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		// encoding/json promotes the fields of untagged embedded structs.
+		if field.Anonymous && !hasName {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				l.redactJSONFields(decoded, embedded)
+				continue
+			}
+		}
+		elem, ok := decoded[name]
+		if !ok {
+			continue
+		}
+		secret := matchesRedactions(name, l.redactions)
+		if tag, ok := field.Tag.Lookup("secret"); ok {
+			secret = tag == "true"
+		}
+		if secret {
+			decoded[name] = "<redacted>"
+		} else {
+			l.redactJSON(elem, field.Type)
+		}
+	}
+}
+
+// jsonName returns the name which encoding/json uses for field, and whether it came from a tag.
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "-", false
+	}
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name, false
+	}
+	if tag == "-" {
+		return "-", true
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name, false
+	}
+	return name, true
+}
+
+// encodeJSON works like json.Marshal, but doesn't escape characters like "<" and ">".
+// The values are logged, not put in HTML.
+func encodeJSON(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,189 @@
+package configs_test
+
+import (
+	"reflect"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type Flag struct {
+	Enabled bool     `json:"enabled"`
+	Percent float64  `json:"percent,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+}
+
+type Webhook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret" secret:"true"`
+	// Password is redacted because of its name.
+	Password string `json:"password"`
+	// PublicPassword is a password in name only.
+	PublicPassword string `json:"public_password" secret:"false"`
+}
+
+type JSONConfig struct {
+	Flags    map[string]Flag `environment:"FLAGS" format:"json"`
+	Matrix   [][]int         `environment:"MATRIX" format:"json" default:"[[1,2],[3]]"`
+	Webhook  *Webhook        `environment:"WEBHOOK" format:"json"`
+	Headers  Headers         `environment:"HEADERS" format:"json"`
+	Anything interface{}     `environment:"ANYTHING" format:"json"`
+}
+
+type Headers struct {
+	Values map[string]string `environment:"VALUES"`
+}
+
+func TestJSONValues(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_FLAGS":    `{"beta": {"enabled": true, "groups": ["staff"]}, "dark_mode": {"enabled": false, "percent": 0.5}}`,
+		"MY_WEBHOOK":  `{"url": "https://example.com/hook", "secret": "s3cret"}`,
+		"MY_HEADERS":  `{"Values": {"X-Team": "core"}}`,
+		"MY_ANYTHING": `[1, "two", {"three": null}]`,
+	})))
+	cfg := JSONConfig{
+		// The JSON replaces defaults, rather than being merged into them.
+		Flags: map[string]Flag{"old": {Enabled: true}},
+	}
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	expectedFlags := map[string]Flag{
+		"beta":      {Enabled: true, Groups: []string{"staff"}},
+		"dark_mode": {Percent: 0.5},
+	}
+	if !reflect.DeepEqual(expectedFlags, cfg.Flags) {
+		t.Errorf("Expected flags %v. Got %v", expectedFlags, cfg.Flags)
+	}
+	if !reflect.DeepEqual([][]int{{1, 2}, {3}}, cfg.Matrix) {
+		t.Errorf("Expected the default matrix. Got %v", cfg.Matrix)
+	}
+	if cfg.Webhook == nil || cfg.Webhook.URL != "https://example.com/hook" || cfg.Webhook.Secret != "s3cret" {
+		t.Errorf("Got unexpected webhook: %#v", cfg.Webhook)
+	}
+	// Struct fields with format:"json" aren't recursed into, so HEADERS_VALUES isn't a key.
+	assertStringsEqual(t, "core", cfg.Headers.Values["X-Team"])
+	expectedAnything := []interface{}{1.0, "two", map[string]interface{}{"three": nil}}
+	if !reflect.DeepEqual(expectedAnything, cfg.Anything) {
+		t.Errorf("Expected %#v. Got %#v", expectedAnything, cfg.Anything)
+	}
+}
+
+func TestEmptyJSONValues(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_MATRIX":  "",
+		"MY_WEBHOOK": "null",
+	})))
+	cfg := JSONConfig{
+		Webhook: &Webhook{URL: "https://example.com"},
+	}
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	if cfg.Matrix != nil || cfg.Webhook != nil {
+		t.Errorf("Expected an empty value and null to clear the fields. Got %v and %v", cfg.Matrix, cfg.Webhook)
+	}
+}
+
+func TestBadJSONValues(t *testing.T) {
+	type Secrets struct {
+		Keys map[string]string `environment:"KEYS" format:"json" secret:"true"`
+	}
+	type BadJSONConfig struct {
+		JSONConfig `environment:"JSON"`
+		Secrets    Secrets `environment:"SECRETS"`
+	}
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_JSON_FLAGS":    `{"beta": {"enabled": "yes"}}`,
+		"MY_JSON_MATRIX":   `[[1, 2], [3,]]`,
+		"MY_JSON_WEBHOOK":  `{"url": "https://example.com"`,
+		"MY_JSON_ANYTHING": `[1, 2] 3`,
+		"MY_SECRETS_KEYS":  `{"primary": "hunter2", "backup": 7}`,
+	})))
+	var cfg BadJSONConfig
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_JSON_FLAGS has a JSON string at byte 26, but beta.enabled must be a bool: got")
+	assertStringContains(t, msg, "MY_JSON_MATRIX must be valid JSON, but has an error at byte 13: invalid character ']' looking for beginning of value: got")
+	assertStringContains(t, msg, "MY_JSON_WEBHOOK must be valid JSON, but has an error at byte 29: unexpected end of JSON input: got")
+	assertStringContains(t, msg, "MY_JSON_ANYTHING must be valid JSON, but has an error at byte 8: invalid character '3' after top-level value: got")
+	assertStringContains(t, msg, "MY_SECRETS_KEYS has a JSON number at byte 34, but backup must be a string\n")
+	assertNotStringContains(t, msg, "hunter2")
+}
+
+func TestLogJSONValues(t *testing.T) {
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(configs.WithLogger(logger))
+	cfg := JSONConfig{
+		Flags: map[string]Flag{
+			"beta": {Enabled: true},
+		},
+		Webhook: &Webhook{
+			URL:            "https://example.com",
+			Secret:         "s3cret",
+			Password:       "hunter2",
+			PublicPassword: "swordfish",
+		},
+		Anything: map[string]interface{}{
+			"db_password": "letmein",
+			"nested":      []interface{}{map[string]interface{}{"password": "opensesame"}},
+		},
+	}
+	loader.Log(&cfg, "MY")
+	logged := logger.String()
+	assertStringContains(t, logged, `MY_FLAGS: {"beta":{"enabled":true}}`)
+	assertStringContains(t, logged, `MY_MATRIX: null`)
+	assertStringContains(t, logged, `MY_WEBHOOK: {"password":"<redacted>","public_password":"swordfish","secret":"<redacted>","url":"https://example.com"}`)
+	assertStringContains(t, logged, `MY_HEADERS: {"Values":null}`)
+	assertStringContains(t, logged, `MY_ANYTHING: {"db_password":"<redacted>","nested":[{"password":"<redacted>"}]}`)
+	for _, secret := range []string{"s3cret", "hunter2", "letmein", "opensesame"} {
+		assertNotStringContains(t, logged, secret)
+	}
+}
+
+func TestDumpJSONValues(t *testing.T) {
+	cfg := JSONConfig{
+		Matrix:  [][]int{{1}, {2, 3}},
+		Webhook: &Webhook{URL: "https://example.com/?a=1&b=<2>", Secret: "s3cret"},
+	}
+	redacted, err := configs.Dump(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Dump() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, "[[1],[2,3]]", redacted["MY_MATRIX"])
+	assertStringsEqual(t, `{"password":"<redacted>","public_password":"","secret":"<redacted>","url":"https://example.com/?a=1&b=<2>"}`, redacted["MY_WEBHOOK"])
+
+	dumped, err := configs.DumpWithSecrets(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected DumpWithSecrets() error: %v", err)
+		return
+	}
+	assertStringsEqual(t, `{"url":"https://example.com/?a=1&b=<2>","secret":"s3cret","password":"","public_password":""}`, dumped["MY_WEBHOOK"])
+	var loaded JSONConfig
+	if err := configs.NewLoader(configs.WithSource(configs.MapSource(dumped))).Load(&loaded, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	if !reflect.DeepEqual(cfg, loaded) {
+		t.Errorf("Expected %#v. Got %#v", cfg, loaded)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	type YAMLConfig struct {
+		Flags map[string]bool `environment:"FLAGS" format:"yaml"`
+	}
+	err := configs.NewLoader().Load(&YAMLConfig{}, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	assertStringContains(t, err.Error(), `YAMLConfig.Flags has format:"yaml", but the only supported format is "json"`)
+}
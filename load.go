@@ -4,8 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -14,191 +14,613 @@ import (
 // It panics if any of the environment variables' values can't be
 // coerced into the type defined on the struct.
 func MustLoadWithPrefix(container interface{}, prefix string) {
-	err := LoadWithPrefix(container, prefix)
-	if err != nil {
-		panic(err)
-	}
+	defaultLoader.MustLoad(container, prefix)
 }
 
 // LoadWithPrefix loads the values of environment variables into a struct.
 // It returns an error if any of the environment variable values don't match
 // the type defined on the struct.
 func LoadWithPrefix(container interface{}, prefix string) error {
-	return visit(container, loader(prefix))
+	return defaultLoader.Load(container, prefix)
+}
+
+// Load builds a T and loads values into it with a Loader made from opts.
+// T must be a struct type. Its properties start with the values from their
+// "default" tags, if they have one.
+//
+// It returns an error if any of the values don't match the type defined on the struct.
+func Load[T any](prefix string, opts ...Option) (T, error) {
+	var container T
+	err := NewLoader(opts...).Load(&container, prefix)
+	return container, err
+}
+
+// MustLoad works like Load, but panics if there's an error.
+func MustLoad[T any](prefix string, opts ...Option) T {
+	container, err := Load[T](prefix, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return container
 }
 
 // loader returns a visitor which populates the struct's properties with
-// environment variables.
-func loader(prefix string) visitor {
-	return visitor(func(environment string, value reflect.Value) *visitError {
-		environment = prefix + environment
-		environmentValue, isSet := os.LookupEnv(environment)
-		if !isSet {
+// values from the Loader's source.
+//
+// If a value isn't in the source, the field's "default" tag is used instead, unless the
+// field already has a value. Fields tagged with required:"true" must get a value
+// from one of those places.
+//
+// What the visitor learns is recorded in state. See loadState.
+func (l *Loader) loader(state *loadState) visitor {
+	return visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			if state.known != nil {
+				state.known[environment] = struct{}{}
+			}
+			if state.secrets != nil {
+				l.addSecretField(state.secrets, environment, field)
+			}
+			environmentValue, setBy, err := l.lookup(environment, field, state.known)
+			if err != nil {
+				return &visitError{
+					error:  err,
+					Key:    environment,
+					Secret: l.isSecretField(environment, field),
+				}
+			}
+			if setBy == "" {
+				return l.loadDefault(environment, field, value)
+			}
+			// Errors about the value name the key it came from, which may be an alias.
+			secret := l.isSecretField(environment, field) || mightHavePassword(field.Type, environmentValue)
+			if l.expand || field.expand {
+				var referenced map[string]struct{}
+				environmentValue, referenced, err = expandValue(l.source, environment, environmentValue)
+				state.references[setBy] = referenced
+			}
+			if err == nil {
+				err = l.parseAndSet(field, value, environmentValue)
+			}
+			if err != nil {
+				return &visitError{
+					error:  err,
+					Key:    setBy,
+					Secret: secret,
+				}
+			}
 			return nil
+		},
+		collection: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			var err error
+			if value.Kind() == reflect.Slice {
+				err = resizeStructSlice(value, sourceIndices(l.source, environment, l.naming))
+			} else {
+				addStructMapKeys(value, sourceMapKeys(l.source, environment, value.Type().Elem(), l.naming))
+			}
+			if err != nil {
+				return &visitError{
+					error: err,
+					Key:   environment,
+				}
+			}
+			return nil
+		},
+		naming:   l.naming,
+		modifies: true,
+	}
+}
+
+// lookup finds the value for a field in the Loader's source. It tries the field's key first,
+// and then each of its aliases. If more than one of them is set, they must agree.
+//
+// It returns the key which the value came from, or "" if none of them are set.
+// Any alias keys are added to known, if it's not nil.
+func (l *Loader) lookup(key string, field *fieldPlan, known map[string]struct{}) (string, string, error) {
+	value, isSet := l.source.Lookup(key)
+	setBy := key
+	for _, fieldAlias := range field.aliases {
+		aliasKey := l.naming.aliasKey(key, field, fieldAlias)
+		if known != nil {
+			known[aliasKey] = struct{}{}
+		}
+		aliasValue, aliasSet := l.source.Lookup(aliasKey)
+		if !aliasSet {
+			continue
+		}
+		if fieldAlias.deprecated {
+			l.logger.Printf("%s is deprecated. Use %s instead.", aliasKey, key)
+		}
+		if !isSet {
+			value, isSet, setBy = aliasValue, true, aliasKey
+		} else if aliasValue != value {
+			return "", "", fmt.Errorf("is set to different values by %s and %s", setBy, aliasKey)
 		}
+	}
+	if !isSet {
+		return "", "", nil
+	}
+	return value, setBy, nil
+}
 
-		switch value.Kind() {
-		case reflect.Bool:
-			return parseAndSetBool(environment, value, environmentValue)
-		case reflect.Int:
-			return parseAndSetInt(environment, value, environmentValue)
-		case reflect.Uint64:
-			return parseAndSetUInt(environment, value, environmentValue, 64)
-		case reflect.Uint32:
-			return parseAndSetUInt(environment, value, environmentValue, 32)
-		case reflect.Uint16:
-			return parseAndSetUInt(environment, value, environmentValue, 16)
-		case reflect.Uint8:
-			return parseAndSetUInt(environment, value, environmentValue, 8)
-		case reflect.String:
-			value.SetString(environmentValue)
-			return nil
-		case reflect.Slice:
-			switch value.Type().Elem().Kind() {
-			case reflect.String:
-				value.Set(reflect.ValueOf(parseCommaSeparatedStrings(environmentValue)))
-				return nil
-			case reflect.Int:
-				return parseAndSetIntSlice(environment, value, environmentValue)
-			default:
-				panic(fmt.Sprintf("loadEnvironmentVisitor() is not yet implement for slices of type %v", value.Type().Elem().Kind()))
+// loadDefault sets value from the field's "default" tag, if it has one and value is
+// still the zero value.
+func (l *Loader) loadDefault(environment string, field *fieldPlan, value reflect.Value) *visitError {
+	if !value.IsZero() {
+		return nil
+	}
+	if !field.hasDefault {
+		if field.required {
+			return &visitError{
+				error: errors.New("is required"),
+				Key:   environment,
 			}
-		case reflect.Struct:
-			switch value.Type().String() {
-			case "big.Int":
-				return parseAndSetBigInt(environment, value, environmentValue)
-			default:
-				panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + value.Type().String())
+		}
+		return nil
+	}
+	if err := l.parseAndSet(field, value, field.defaultValue); err != nil {
+		return &visitError{
+			error: fmt.Errorf("has an invalid default %q: %v", field.defaultValue, err),
+			Key:   environment,
+		}
+	}
+	return nil
+}
+
+// sourceIndices finds the indices used by keys like "{collection}_0_HOST" and "{collection}_1_HOST".
+func sourceIndices(src Source, collection string, naming keyNaming) []int {
+	var indices []int
+	seen := make(map[int]struct{})
+	prefix := collection + naming.separator
+	for _, key := range keysWithPrefix(src, prefix) {
+		segment := strings.SplitN(key[len(prefix):], naming.separator, 2)[0]
+		index, err := strconv.Atoi(segment)
+		// Reject things like "+1" or "01", so that each index has exactly one spelling.
+		if err != nil || index < 0 || strconv.Itoa(index) != segment {
+			continue
+		}
+		if _, ok := seen[index]; !ok {
+			seen[index] = struct{}{}
+			indices = append(indices, index)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// resizeStructSlice makes toSet big enough to hold the elements at indices.
+// Elements which already exist are kept, so that they act as defaults.
+// If there are no indices, toSet is left unchanged.
+func resizeStructSlice(toSet reflect.Value, indices []int) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	for i, index := range indices {
+		if i != index {
+			return fmt.Errorf("is missing index %d", i)
+		}
+	}
+	resized := reflect.MakeSlice(toSet.Type(), len(indices), len(indices))
+	reflect.Copy(resized, toSet)
+	toSet.Set(resized)
+	return nil
+}
+
+// sourceMapKeys finds the map keys used by keys like "{collection}_PAYMENTS_URL" and "{collection}_SEARCH_URL".
+// elemType is the type of the map's values. Its properties are used to tell where the
+// map key ends, so map keys may contain the separator too.
+func sourceMapKeys(src Source, collection string, elemType reflect.Type, naming keyNaming) []string {
+	leaves, collections := relativeKeys(elemType, naming)
+	var mapKeys []string
+	seen := make(map[string]struct{})
+	prefix := collection + naming.separator
+	for _, key := range keysWithPrefix(src, prefix) {
+		rest := key[len(prefix):]
+		// If a key could match more than one property, assume the map key is the shortest option.
+		mapKey := ""
+		for _, leaf := range leaves {
+			if suffix := naming.separator + leaf; strings.HasSuffix(rest, suffix) {
+				mapKey = shorter(mapKey, rest[:len(rest)-len(suffix)])
 			}
-		case reflect.Ptr:
-			switch value.Type().String() {
-			case "*big.Int":
-				return parseAndSetBigIntPointer(environment, value, environmentValue)
-			default:
-				panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + value.Type().String())
+		}
+		for _, inner := range collections {
+			if index := strings.Index(rest, naming.separator+inner+naming.separator); index > 0 {
+				mapKey = shorter(mapKey, rest[:index])
 			}
-		default:
-			panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + value.String())
 		}
+		if _, ok := seen[mapKey]; mapKey != "" && !ok {
+			seen[mapKey] = struct{}{}
+			mapKeys = append(mapKeys, mapKey)
+		}
+	}
+	return mapKeys
+}
+
+// shorter returns the shorter of two non-empty strings. If one is empty, the other is returned.
+func shorter(a string, b string) string {
+	if a == "" || (b != "" && len(b) < len(a)) {
+		return b
+	}
+	return a
+}
+
+// relativeKeys returns the keys of theType's leaves without any prefix, like "URL",
+// and of any slices or maps of structs inside it, like "BACKENDS".
+func relativeKeys(theType reflect.Type, naming keyNaming) (leaves []string, collections []string) {
+	visit(reflect.New(theType).Interface(), "", visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			leaves = append(leaves, environment)
+			return nil
+		},
+		collection: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			collections = append(collections, environment)
+			return nil
+		},
+		naming: naming,
 	})
+	return leaves, collections
+}
+
+// addStructMapKeys makes sure that toSet has an element for each of the keys.
+// Elements which already exist are kept, so that they act as defaults.
+func addStructMapKeys(toSet reflect.Value, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if toSet.IsNil() {
+		toSet.Set(reflect.MakeMapWithSize(toSet.Type(), len(keys)))
+	}
+	for _, key := range keys {
+		mapKey := reflect.ValueOf(key).Convert(toSet.Type().Key())
+		if !toSet.MapIndex(mapKey).IsValid() {
+			toSet.SetMapIndex(mapKey, reflect.Zero(toSet.Type().Elem()))
+		}
+	}
+}
+
+// parseAndSet parses value into toSet, using any options from the field's tags.
+func (l *Loader) parseAndSet(field *fieldPlan, toSet reflect.Value, value string) error {
+	if field.format == jsonFormat {
+		return parseAndSetJSON(toSet, value)
+	}
+	if isNetworkType(toSet.Type()) {
+		// This catches net.IP, which is a slice but holds a single value.
+		return parseAndSetNetwork(toSet, value, l.parseOptionsFor(field).network)
+	}
+	switch toSet.Kind() {
+	case reflect.Slice:
+		if isBytes(toSet.Type()) {
+			return parseAndSetBytes(toSet, value, field.encoding)
+		}
+		separator := orDefault(field.separator, l.separator)
+		if isNetworkType(toSet.Type().Elem()) {
+			return parseAndSetScalarSlice(toSet, value, separator, l.parseOptionsFor(field))
+		}
+		switch toSet.Type().Elem().Kind() {
+		case reflect.String:
+			toSet.Set(reflect.ValueOf(parseSeparatedStrings(value, separator)))
+			return nil
+		case reflect.Int:
+			return parseAndSetIntSlice(toSet, value, separator, l.parseOptionsFor(field).base)
+		default:
+			panic(fmt.Sprintf("loadEnvironmentVisitor() is not yet implement for slices of type %v", toSet.Type().Elem().Kind()))
+		}
+	case reflect.Map:
+		return parseAndSetMap(toSet, value, orDefault(field.separator, l.separator), orDefault(field.kvSeparator, l.kvSeparator), l.parseOptionsFor(field))
+	default:
+		return parseAndSetScalar(toSet, value, l.parseOptionsFor(field))
+	}
+}
+
+// canParse returns true if parseAndSet knows how to load the field's values.
+// It must agree with parseAndSet and parseAndSetScalar, so that analyze() can
+// reject the fields which they'd panic on.
+func canParse(field *fieldPlan) bool {
+	theType := field.Type
+	if field.format == jsonFormat || isNetworkType(theType) {
+		return true
+	}
+	switch theType.Kind() {
+	case reflect.Slice:
+		elem := theType.Elem()
+		return isBytes(theType) || isNetworkType(elem) || elem == stringType || elem == intType
+	case reflect.Map:
+		// Pointer keys would never be equal, so repeated keys couldn't be caught.
+		return isScalar(theType.Key()) && theType.Key().Kind() != reflect.Ptr && isScalar(theType.Elem())
+	default:
+		return isScalar(theType)
+	}
+}
+
+var stringType = reflect.TypeOf("")
+var intType = reflect.TypeOf(0)
+
+// isScalar returns true if parseAndSetScalar can parse values of theType.
+func isScalar(theType reflect.Type) bool {
+	if isNetworkType(theType) {
+		return true
+	}
+	switch theType {
+	case byteSizeType, percentType, durationType:
+		return true
+	}
+	switch theType.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.String:
+		return true
+	case reflect.Struct:
+		return theType.String() == "big.Int"
+	case reflect.Ptr:
+		return theType.String() == "*big.Int"
+	default:
+		return false
+	}
 }
 
-func parseAndSetBool(env string, toSet reflect.Value, value string) *visitError {
+// parseAndSetScalar parses a single value into toSet. It's used for struct properties
+// as well as the keys and values of maps.
+func parseAndSetScalar(toSet reflect.Value, value string, opts parseOptions) error {
+	if isNetworkType(toSet.Type()) {
+		return parseAndSetNetwork(toSet, value, opts.network)
+	}
+	switch toSet.Type() {
+	case byteSizeType:
+		return parseAndSetByteSize(toSet, value)
+	case percentType:
+		return parseAndSetPercent(toSet, value)
+	case durationType:
+		return parseAndSetDuration(toSet, value)
+	}
+
+	switch toSet.Kind() {
+	case reflect.Bool:
+		if opts.strictBools {
+			return parseAndSetStrictBool(toSet, value)
+		}
+		return parseAndSetBool(toSet, value)
+	case reflect.Int:
+		return parseAndSetInt(toSet, value, opts.base)
+	case reflect.Uint64:
+		return parseAndSetUInt(toSet, value, 64, opts.base)
+	case reflect.Uint32:
+		return parseAndSetUInt(toSet, value, 32, opts.base)
+	case reflect.Uint16:
+		return parseAndSetUInt(toSet, value, 16, opts.base)
+	case reflect.Uint8:
+		return parseAndSetUInt(toSet, value, 8, opts.base)
+	case reflect.String:
+		toSet.SetString(value)
+		return nil
+	case reflect.Struct:
+		switch toSet.Type().String() {
+		case "big.Int":
+			return parseAndSetBigInt(toSet, value, opts.base)
+		default:
+			panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + toSet.Type().String())
+		}
+	case reflect.Ptr:
+		switch toSet.Type().String() {
+		case "*big.Int":
+			return parseAndSetBigIntPointer(toSet, value, opts.base)
+		default:
+			panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + toSet.Type().String())
+		}
+	default:
+		panic("loadEnvironmentVisitor() hasn't yet implemented parsing for type " + toSet.String())
+	}
+}
+
+// parseOptions holds the settings from a field's tags which change how its values are parsed.
+type parseOptions struct {
+	// strictBools only allows "true" and "false" as boolean values.
+	strictBools bool
+	// base is used to parse integers. As with strconv.ParseInt, 0 means that the
+	// base is implied by the value's prefix, and underscores are allowed.
+	base int
+	// network validates URLs, IP addresses and host:port pairs.
+	network networkOptions
+}
+
+// parseOptionsFor combines the parse options from the field's tags with the Loader's.
+func (l *Loader) parseOptionsFor(field *fieldPlan) parseOptions {
+	opts := field.parse
+	opts.strictBools = opts.strictBools || l.strictBools
+	return opts
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value string, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
+
+func parseAndSetBool(toSet reflect.Value, value string) error {
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "yes", "on":
+		toSet.SetBool(true)
+	case "0", "f", "false", "no", "off":
+		toSet.SetBool(false)
+	default:
+		return errors.New("must be true/false, yes/no, on/off, t/f or 1/0 (case-insensitive)")
+	}
+	return nil
+}
+
+func parseAndSetStrictBool(toSet reflect.Value, value string) error {
 	switch value {
 	case "true":
 		toSet.SetBool(true)
 	case "false":
 		toSet.SetBool(false)
 	default:
-		return &visitError{
-			error: errors.New(`must be "true" or "false"`),
-			Key:   env,
-		}
+		return errors.New(`must be "true" or "false"`)
 	}
 	return nil
 }
 
-func parseAndSetInt(env string, toSet reflect.Value, value string) *visitError {
-	parsed, err := parseInt(value)
-	if err != nil {
-		return &visitError{
-			error: errors.New("must be an int"),
-			Key:   env,
+func parseAndSetInt(toSet reflect.Value, value string, base int) error {
+	parsed, err := strconv.ParseInt(value, base, toSet.Type().Bits())
+	if casted, ok := err.(*strconv.NumError); ok && casted != nil {
+		if casted.Err == strconv.ErrRange && parsed < 0 {
+			return fmt.Errorf("has a min value of %d", parsed)
 		}
+		if casted.Err == strconv.ErrRange {
+			return fmt.Errorf("has a max value of %d", parsed)
+		}
+		return errors.New("must be " + describeInt("int", base))
 	}
 	toSet.SetInt(parsed)
 	return nil
 }
 
-func parseAndSetUInt(env string, toSet reflect.Value, value string, bitSize int) *visitError {
-	parsed, err := strconv.ParseUint(value, 10, bitSize)
+func parseAndSetUInt(toSet reflect.Value, value string, bitSize int, base int) error {
+	parsed, err := strconv.ParseUint(value, base, bitSize)
 	if casted, ok := err.(*strconv.NumError); ok && casted != nil {
 		if casted.Err == strconv.ErrRange {
-			return &visitError{
-				error: fmt.Errorf("has a max value of %d", parsed),
-				Key:   env,
-			}
+			return fmt.Errorf("has a max value of %d", parsed)
 		}
-		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
-			return &visitError{
-				error: errors.New("has a min value of 0"),
-				Key:   env,
-			}
-		}
-		return &visitError{
-			error: errors.New("must be a uint" + strconv.FormatInt(int64(bitSize), 10)),
-			Key:   env,
+		// ParseUint doesn't accept any negative numbers, even ones which are too big for an int64.
+		if negative, ok := parseBigInt(value, base); ok && negative.Sign() < 0 {
+			return errors.New("has a min value of 0")
 		}
+		return errors.New("must be " + describeInt("uint"+strconv.FormatInt(int64(bitSize), 10), base))
 	}
 	toSet.SetUint(parsed)
 	return nil
 }
 
-func parseInt(value string) (int64, error) {
-	return strconv.ParseInt(value, 10, 64)
+// describeInt describes an integer type which was parsed in base, for use in error messages.
+func describeInt(name string, base int) string {
+	article := "a "
+	if strings.HasPrefix(name, "int") {
+		article = "an "
+	}
+	switch base {
+	case 0:
+		return article + name + " with an optional 0x, 0o or 0b prefix"
+	case 10:
+		if name == "big.Int" {
+			return "a base-10 big.Int"
+		}
+		return article + name
+	default:
+		return fmt.Sprintf("a base-%d %s", base, name)
+	}
 }
 
-func parseAndSetBigInt(env string, toSet reflect.Value, value string) *visitError {
-	parsed, ok := parseBigInt(value)
+func parseAndSetBigInt(toSet reflect.Value, value string, base int) error {
+	parsed, ok := parseBigInt(value, base)
 	if !ok {
-		return &visitError{
-			error: errors.New("must be a base-10 big.Int"),
-			Key:   env,
-		}
+		return errors.New("must be " + describeInt("big.Int", base))
 	}
 	toSet.Set(reflect.ValueOf(parsed))
 	return nil
 }
 
-func parseAndSetBigIntPointer(env string, toSet reflect.Value, value string) *visitError {
-	parsed, ok := parseBigInt(value)
+func parseAndSetBigIntPointer(toSet reflect.Value, value string, base int) error {
+	parsed, ok := parseBigInt(value, base)
 	if !ok {
-		return &visitError{
-			error: errors.New("must be a base-10 big.Int"),
-			Key:   env,
-		}
+		return errors.New("must be " + describeInt("big.Int", base))
 	}
 	toSet.Set(reflect.ValueOf(&parsed))
 	return nil
 }
 
-func parseBigInt(value string) (big.Int, bool) {
+func parseBigInt(value string, base int) (big.Int, bool) {
 	parsed := big.Int{}
-	_, ok := parsed.SetString(value, 10)
+	_, ok := parsed.SetString(value, base)
 	return parsed, ok
 }
 
-func parseCommaSeparatedStrings(value string) []string {
+func parseSeparatedStrings(value string, separator string) []string {
 	if value == "" {
 		return nil
 	}
-	return strings.Split(value, ",")
+	return strings.Split(value, separator)
 }
 
-func parseAndSetIntSlice(env string, toSet reflect.Value, value string) *visitError {
-	parsed, err := parseCommaSeparatedInts(value)
+func parseAndSetIntSlice(toSet reflect.Value, value string, separator string, base int) error {
+	parsed, err := parseSeparatedInts(value, separator, base)
 	if err != nil {
-		return &visitError{
-			error: err,
-			Key:   env,
-		}
+		return err
 	}
 	toSet.Set(reflect.ValueOf(parsed))
 	return nil
 }
 
-func parseCommaSeparatedInts(value string) ([]int, error) {
+func parseSeparatedInts(value string, separator string, base int) ([]int, error) {
 	if value == "" {
 		return nil, nil
 	}
-	stringSlice := strings.Split(value, ",")
+	stringSlice := strings.Split(value, separator)
 	intSlice := make([]int, len(stringSlice))
 	for i := 0; i < len(stringSlice); i++ {
-		parsed, err := strconv.Atoi(stringSlice[i])
+		parsed, err := strconv.ParseInt(stringSlice[i], base, strconv.IntSize)
 		if err != nil {
-			return nil, fmt.Errorf(`must be a comma-separated list of ints: index %d is invalid`, i)
+			return nil, fmt.Errorf(`must be a %s list of ints: index %d is invalid`, describeSeparator(separator), i)
 		}
-		intSlice[i] = parsed
+		intSlice[i] = int(parsed)
 	}
 	return intSlice, nil
 }
+
+// parseAndSetScalarSlice parses a list like "a,b,c" into toSet. Each element is parsed
+// with the same rules as scalar struct properties.
+func parseAndSetScalarSlice(toSet reflect.Value, value string, separator string, opts parseOptions) error {
+	if value == "" {
+		toSet.Set(reflect.Zero(toSet.Type()))
+		return nil
+	}
+	parts := strings.Split(value, separator)
+	parsed := reflect.MakeSlice(toSet.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := parseAndSetScalar(parsed.Index(i), part, opts); err != nil {
+			return fmt.Errorf("must be a %s list: index %d is invalid: %v", describeSeparator(separator), i, err)
+		}
+	}
+	toSet.Set(parsed)
+	return nil
+}
+
+// parseAndSetMap parses a list of key/value pairs like "k1=v1,k2=v2" into a map.
+// The keys and values are parsed with the same rules as scalar struct properties.
+func parseAndSetMap(toSet reflect.Value, value string, separator string, kvSeparator string, opts parseOptions) error {
+	if value == "" {
+		toSet.Set(reflect.Zero(toSet.Type()))
+		return nil
+	}
+	mapType := toSet.Type()
+	pairs := strings.Split(value, separator)
+	parsed := reflect.MakeMapWithSize(mapType, len(pairs))
+	seen := make(map[interface{}]int, len(pairs))
+	for i, pair := range pairs {
+		keyAndValue := strings.SplitN(pair, kvSeparator, 2)
+		if len(keyAndValue) != 2 {
+			return fmt.Errorf(`must be a %s list of key%svalue pairs: index %d is malformed`, describeSeparator(separator), kvSeparator, i)
+		}
+		key := reflect.New(mapType.Key()).Elem()
+		if err := parseAndSetScalar(key, keyAndValue[0], opts); err != nil {
+			return fmt.Errorf("index %d has an invalid key: %v", i, err)
+		}
+		if first, ok := seen[key.Interface()]; ok {
+			return fmt.Errorf("index %d repeats the key from index %d", i, first)
+		}
+		seen[key.Interface()] = i
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := parseAndSetScalar(elem, keyAndValue[1], opts); err != nil {
+			return fmt.Errorf("index %d has an invalid value: %v", i, err)
+		}
+		parsed.SetMapIndex(key, elem)
+	}
+	toSet.Set(parsed)
+	return nil
+}
+
+// describeSeparator names a separator for use in error messages.
+func describeSeparator(separator string) string {
+	if separator == "," {
+		return "comma-separated"
+	}
+	return fmt.Sprintf("%q-separated", separator)
+}
@@ -0,0 +1,57 @@
+package configs_test
+
+import (
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+var benchmarkValues = map[string]string{
+	"MY_BOOLEAN":                "true",
+	"MY_INT":                    "10",
+	"MY_UINT_8":                 "6",
+	"MY_UINT_64":                "9",
+	"MY_BIG_INT":                "9571",
+	"MY_STRING":                 "someString",
+	"MY_INT_SLICE":              "1,-2",
+	"MY_STRING_MAP":             "a=b,c=d",
+	"MY_NESTED_VALUE":           "20",
+	"MY_NESTED_BIG_INT_POINTER": "112",
+	"MY_BACKENDS_0_HOST":        "a.example.com",
+	"MY_BACKENDS_1_PORT":        "81",
+}
+
+func BenchmarkLoad(b *testing.B) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(benchmarkValues)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		if err := loader.Load(&cfg, "MY"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadUnset(b *testing.B) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(nil)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var cfg Config
+		if err := loader.Load(&cfg, "MY"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadParallel(b *testing.B) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(benchmarkValues)))
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var cfg Config
+			if err := loader.Load(&cfg, "MY"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -12,18 +12,23 @@ import (
 )
 
 type Config struct {
-	Boolean      bool     `environment:"BOOLEAN"`
-	Int          int      `environment:"INT"`
-	UINT_8       uint8    `environment:"UINT_8"`
-	UINT_16      uint16   `environment:"UINT_16"`
-	UINT_32      uint32   `environment:"UINT_32"`
-	UINT_64      uint64   `environment:"UINT_64"`
-	BigInt       big.Int  `environment:"BIG_INT"`
-	String       string   `environment:"STRING"`
-	IntSlice     []int    `environment:"INT_SLICE"`
-	StringSlice  []string `environment:"STRING_SLICE"`
-	Nested       *Nested  `environment:"NESTED"`
-	SomePassword string   `environment:"SOME_PASSWORD"`
+	Boolean      bool                `environment:"BOOLEAN"`
+	Int          int                 `environment:"INT"`
+	UINT_8       uint8               `environment:"UINT_8"`
+	UINT_16      uint16              `environment:"UINT_16"`
+	UINT_32      uint32              `environment:"UINT_32"`
+	UINT_64      uint64              `environment:"UINT_64"`
+	BigInt       big.Int             `environment:"BIG_INT"`
+	String       string              `environment:"STRING"`
+	IntSlice     []int               `environment:"INT_SLICE"`
+	StringSlice  []string            `environment:"STRING_SLICE"`
+	StringMap    map[string]string   `environment:"STRING_MAP"`
+	IntMap       map[string]int      `environment:"INT_MAP"`
+	UintBoolMap  map[uint8]bool      `environment:"UINT_BOOL_MAP" separator:";" kvseparator:":"`
+	Nested       *Nested             `environment:"NESTED"`
+	Backends     []Backend           `environment:"BACKENDS"`
+	Upstreams    map[string]Upstream `environment:"UPSTREAMS"`
+	SomePassword string              `environment:"SOME_PASSWORD"`
 }
 
 type Nested struct {
@@ -31,6 +36,17 @@ type Nested struct {
 	BigIntPointer *big.Int `environment:"BIG_INT_POINTER"`
 }
 
+type Backend struct {
+	Host   string `environment:"HOST"`
+	Port   int    `environment:"PORT"`
+	Weight int    `environment:"WEIGHT"`
+}
+
+type Upstream struct {
+	URL            string `environment:"URL"`
+	TimeoutSeconds int    `environment:"TIMEOUT_SECONDS"`
+}
+
 func TestWellFormedValues(t *testing.T) {
 	defer setEnv(t, "MY_BOOLEAN", "true")()
 	defer setEnv(t, "MY_INT", "10")()
@@ -42,6 +58,9 @@ func TestWellFormedValues(t *testing.T) {
 	defer setEnv(t, "MY_STRING", "someString")()
 	defer setEnv(t, "MY_INT_SLICE", "1,-2")()
 	defer setEnv(t, "MY_STRING_SLICE", "abc,def")()
+	defer setEnv(t, "MY_STRING_MAP", "a=b,c=d=e")()
+	defer setEnv(t, "MY_INT_MAP", "a=1,b=-2")()
+	defer setEnv(t, "MY_UINT_BOOL_MAP", "1:true;2:false")()
 	defer setEnv(t, "MY_NESTED_VALUE", "20")()
 	defer setEnv(t, "MY_NESTED_BIG_INT_POINTER", "112")()
 	defer setEnv(t, "MY_SOME_PASSWORD", "secret")()
@@ -63,6 +82,13 @@ func TestWellFormedValues(t *testing.T) {
 	assertBigIntsEqual(t, big.NewInt(9571), &cfg.BigInt)
 	assertIntSlicesEqual(t, []int{1, -2}, cfg.IntSlice)
 	assertStringSlicesEqual(t, []string{"abc", "def"}, cfg.StringSlice)
+	assertStringMapsEqual(t, map[string]string{"a": "b", "c": "d=e"}, cfg.StringMap)
+	assertIntsEqual(t, 2, len(cfg.IntMap))
+	assertIntsEqual(t, 1, cfg.IntMap["a"])
+	assertIntsEqual(t, -2, cfg.IntMap["b"])
+	assertIntsEqual(t, 2, len(cfg.UintBoolMap))
+	assertBoolsEqual(t, true, cfg.UintBoolMap[1])
+	assertBoolsEqual(t, false, cfg.UintBoolMap[2])
 	assertIntsEqual(t, 20, cfg.Nested.Value)
 	assertBigIntsEqual(t, big.NewInt(112), cfg.Nested.BigIntPointer)
 
@@ -81,6 +107,7 @@ func TestWellFormedValues(t *testing.T) {
 	assertStringContains(t, logged, "MY_STRING: \"someString\"")
 	assertStringContains(t, logged, "MY_INT_SLICE: []int{1, -2}")
 	assertStringContains(t, logged, "MY_STRING_SLICE: []string{\"abc\", \"def\"}")
+	assertStringContains(t, logged, "MY_INT_MAP: map[string]int{\"a\":1, \"b\":-2}")
 	assertStringContains(t, logged, "MY_NESTED_VALUE: 20")
 	assertStringContains(t, logged, "MY_NESTED_BIG_INT_POINTER: 112")
 	assertStringContains(t, logged, "MY_SOME_PASSWORD: <redacted>")
@@ -108,7 +135,7 @@ func TestBadValues(t *testing.T) {
 	}
 
 	msg := err.Error()
-	assertStringContains(t, msg, `MY_BOOLEAN must be "true" or "false": got "3"`)
+	assertStringContains(t, msg, `MY_BOOLEAN must be true/false, yes/no, on/off, t/f or 1/0 (case-insensitive): got "3"`)
 	assertStringContains(t, msg, `MY_UINT_8 must be a uint8: got "a"`)
 	assertStringContains(t, msg, `MY_UINT_16 must be a uint16: got "b"`)
 	assertStringContains(t, msg, `MY_UINT_32 must be a uint32: got "c"`)
@@ -120,6 +147,371 @@ func TestBadValues(t *testing.T) {
 	assertStringContains(t, msg, `MY_NESTED_VALUE must be an int: got "bar"`)
 }
 
+func TestBooleanSpellings(t *testing.T) {
+	spellings := map[string]bool{
+		"true":  true,
+		"TRUE":  true,
+		"T":     true,
+		"1":     true,
+		"Yes":   true,
+		"on":    true,
+		"false": false,
+		"False": false,
+		"f":     false,
+		"0":     false,
+		"NO":    false,
+		"off":   false,
+	}
+	for spelling, expected := range spellings {
+		t.Run(spelling, func(t *testing.T) {
+			defer setEnv(t, "MY_BOOLEAN", spelling)()
+			cfg := Config{
+				Boolean: !expected,
+			}
+			if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+				t.Errorf("Got unexpected Load() error: %v", err)
+				return
+			}
+			assertBoolsEqual(t, expected, cfg.Boolean)
+		})
+	}
+}
+
+func TestStrictBooleans(t *testing.T) {
+	defer setEnv(t, "MY_STRICT", "yes")()
+	cfg := struct {
+		Strict bool `environment:"STRICT" strict:"true"`
+	}{}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `MY_STRICT must be "true" or "false": got "yes"`)
+}
+
+func TestIntBases(t *testing.T) {
+	defer setEnv(t, "MY_MODE", "0o755")()
+	defer setEnv(t, "MY_ID", "0x1F")()
+	defer setEnv(t, "MY_COUNT", "1_000_000")()
+	defer setEnv(t, "MY_HUGE", "0xffffffffffffffffffff")()
+	defer setEnv(t, "MY_FLAGS", "0b11,0x10")()
+	defer setEnv(t, "MY_HEX", "ff")()
+	cfg := struct {
+		Mode  uint32   `environment:"MODE" base:"0"`
+		ID    uint8    `environment:"ID" base:"0"`
+		Count int      `environment:"COUNT" base:"0"`
+		Huge  *big.Int `environment:"HUGE" base:"0"`
+		Flags []int    `environment:"FLAGS" base:"0"`
+		Hex   int      `environment:"HEX" base:"16"`
+	}{}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 0755, int(cfg.Mode))
+	assertIntsEqual(t, 31, int(cfg.ID))
+	assertIntsEqual(t, 1000000, cfg.Count)
+	expected, _ := new(big.Int).SetString("ffffffffffffffffffff", 16)
+	assertBigIntsEqual(t, expected, cfg.Huge)
+	assertIntSlicesEqual(t, []int{3, 16}, cfg.Flags)
+	assertIntsEqual(t, 255, cfg.Hex)
+}
+
+func TestBadIntBases(t *testing.T) {
+	defer setEnv(t, "MY_INT", "0x1F")()
+	defer setEnv(t, "MY_MODE", "0o758")()
+	defer setEnv(t, "MY_NEGATIVE", "-0x1")()
+	cfg := struct {
+		Int      int    `environment:"INT"`
+		Mode     uint32 `environment:"MODE" base:"0"`
+		Negative uint8  `environment:"NEGATIVE" base:"0"`
+	}{}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `MY_INT must be an int: got "0x1F"`)
+	assertStringContains(t, msg, `MY_MODE must be a uint32 with an optional 0x, 0o or 0b prefix: got "0o758"`)
+	assertStringContains(t, msg, `MY_NEGATIVE has a min value of 0: got "-0x1"`)
+}
+
+func TestBadBaseTags(t *testing.T) {
+	type BadBases struct {
+		Letter int    `environment:"LETTER" base:"z"`
+		One    uint8  `environment:"ONE" base:"1"`
+		Big    uint64 `environment:"BIG" base:"37"`
+	}
+	err := configs.LoadWithPrefix(&BadBases{}, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `BadBases.Letter has base:"z", but the base must be 0 or between 2 and 36`)
+	assertStringContains(t, msg, `BadBases.One has base:"1", but the base must be 0 or between 2 and 36`)
+	assertStringContains(t, msg, `BadBases.Big has base:"37", but the base must be 0 or between 2 and 36`)
+	if _, err := configs.Describe(&BadBases{}, "MY"); err == nil {
+		t.Errorf("Missing expected Describe() error")
+	}
+}
+
+func TestByteSizesAndPercents(t *testing.T) {
+	defer setEnv(t, "MY_MEMORY", "512MiB")()
+	defer setEnv(t, "MY_CACHE", "1.5gb")()
+	defer setEnv(t, "MY_RAW", "100")()
+	defer setEnv(t, "MY_RATIO", "75%")()
+	cfg := struct {
+		Memory configs.ByteSize `environment:"MEMORY"`
+		Cache  configs.ByteSize `environment:"CACHE"`
+		Raw    configs.ByteSize `environment:"RAW"`
+		Ratio  configs.Percent  `environment:"RATIO"`
+	}{}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 512*1024*1024, int(cfg.Memory))
+	assertIntsEqual(t, 1500000000, int(cfg.Cache))
+	assertIntsEqual(t, 100, int(cfg.Raw))
+	if cfg.Ratio != 0.75 {
+		t.Errorf("Expected 0.75. Got %v", float64(cfg.Ratio))
+	}
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	configs.LogWithPrefix(&cfg, "MY")
+	logged := buf.String()
+	assertStringContains(t, logged, "MY_MEMORY: 512MiB")
+	assertStringContains(t, logged, "MY_CACHE: 1500MB")
+	assertStringContains(t, logged, "MY_RAW: 100B")
+	assertStringContains(t, logged, "MY_RATIO: 75%")
+}
+
+func TestBadByteSizesAndPercents(t *testing.T) {
+	defer setEnv(t, "MY_MEMORY", "512XB")()
+	defer setEnv(t, "MY_CACHE", "0.1B")()
+	defer setEnv(t, "MY_RAW", "17EiB")()
+	defer setEnv(t, "MY_RATIO", "0.75")()
+	defer setEnv(t, "MY_FRACTION", "1/2GB")()
+	defer setEnv(t, "MY_HUGE", "1e1000000000%")()
+	cfg := struct {
+		Memory   configs.ByteSize `environment:"MEMORY"`
+		Cache    configs.ByteSize `environment:"CACHE"`
+		Raw      configs.ByteSize `environment:"RAW"`
+		Ratio    configs.Percent  `environment:"RATIO"`
+		Fraction configs.ByteSize `environment:"FRACTION"`
+		Huge     configs.Percent  `environment:"HUGE"`
+	}{}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `MY_MEMORY must be a size like 512MiB or 2GB: got "512XB"`)
+	assertStringContains(t, msg, `MY_CACHE must be a whole number of bytes: got "0.1B"`)
+	assertStringContains(t, msg, `MY_RAW has a max value of 18446744073709551615 bytes: got "17EiB"`)
+	assertStringContains(t, msg, `MY_RATIO must be a percentage like 75%: got "0.75"`)
+	assertStringContains(t, msg, `MY_FRACTION must be a size like 512MiB or 2GB: got "1/2GB"`)
+	assertStringContains(t, msg, `MY_HUGE must be a percentage like 75%: got "1e1000000000%"`)
+}
+
+func TestBadMapValues(t *testing.T) {
+	defer setEnv(t, "MY_STRING_MAP", "a=b,c")()
+	defer setEnv(t, "MY_INT_MAP", "a=1,b=x")()
+	defer setEnv(t, "MY_UINT_BOOL_MAP", "1:true;1:false")()
+	cfg := Config{
+		Nested: &Nested{},
+	}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `MY_STRING_MAP must be a comma-separated list of key=value pairs: index 1 is malformed: got "a=b,c"`)
+	assertStringContains(t, msg, `MY_INT_MAP index 1 has an invalid value: must be an int: got "a=1,b=x"`)
+	assertStringContains(t, msg, `MY_UINT_BOOL_MAP index 1 repeats the key from index 0: got "1:true;1:false"`)
+}
+
+func TestStructSlices(t *testing.T) {
+	defer setEnv(t, "MY_BACKENDS_0_HOST", "a.example.com")()
+	defer setEnv(t, "MY_BACKENDS_0_PORT", "80")()
+	defer setEnv(t, "MY_BACKENDS_1_HOST", "b.example.com")()
+	defer setEnv(t, "MY_BACKENDS_1_WEIGHT", "3")()
+	cfg := Config{
+		Nested: &Nested{},
+		Backends: []Backend{
+			{Port: 8080, Weight: 1},
+			{Port: 8081, Weight: 1},
+			{Port: 8082, Weight: 1},
+		},
+	}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	if len(cfg.Backends) != 2 {
+		t.Fatalf("Expected 2 backends. Got %d", len(cfg.Backends))
+	}
+	assertStringsEqual(t, "a.example.com", cfg.Backends[0].Host)
+	assertIntsEqual(t, 80, cfg.Backends[0].Port)
+	assertIntsEqual(t, 1, cfg.Backends[0].Weight)
+	assertStringsEqual(t, "b.example.com", cfg.Backends[1].Host)
+	assertIntsEqual(t, 8081, cfg.Backends[1].Port)
+	assertIntsEqual(t, 3, cfg.Backends[1].Weight)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	configs.LogWithPrefix(&cfg, "MY")
+	logged := buf.String()
+	assertStringContains(t, logged, "MY_BACKENDS_0_HOST: \"a.example.com\"")
+	assertStringContains(t, logged, "MY_BACKENDS_0_PORT: 80")
+	assertStringContains(t, logged, "MY_BACKENDS_1_HOST: \"b.example.com\"")
+	assertStringContains(t, logged, "MY_BACKENDS_1_WEIGHT: 3")
+}
+
+func TestStructSliceGaps(t *testing.T) {
+	defer setEnv(t, "MY_BACKENDS_0_HOST", "a.example.com")()
+	defer setEnv(t, "MY_BACKENDS_2_HOST", "c.example.com")()
+	cfg := Config{
+		Nested: &Nested{},
+	}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_BACKENDS is missing index 1\n")
+}
+
+func TestStructMaps(t *testing.T) {
+	defer setEnv(t, "MY_UPSTREAMS_PAYMENTS_URL", "https://payments.example.com")()
+	defer setEnv(t, "MY_UPSTREAMS_LEGACY_SEARCH_URL", "https://search.example.com")()
+	defer setEnv(t, "MY_UPSTREAMS_LEGACY_SEARCH_TIMEOUT_SECONDS", "30")()
+	cfg := Config{
+		Upstreams: map[string]Upstream{
+			"PAYMENTS": {TimeoutSeconds: 5},
+			"ADS":      {URL: "https://ads.example.com", TimeoutSeconds: 1},
+		},
+	}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 3, len(cfg.Upstreams))
+	assertStringsEqual(t, "https://payments.example.com", cfg.Upstreams["PAYMENTS"].URL)
+	assertIntsEqual(t, 5, cfg.Upstreams["PAYMENTS"].TimeoutSeconds)
+	assertStringsEqual(t, "https://search.example.com", cfg.Upstreams["LEGACY_SEARCH"].URL)
+	assertIntsEqual(t, 30, cfg.Upstreams["LEGACY_SEARCH"].TimeoutSeconds)
+	assertStringsEqual(t, "https://ads.example.com", cfg.Upstreams["ADS"].URL)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	configs.LogWithPrefix(&cfg, "MY")
+	logged := buf.String()
+	assertStringContains(t, logged, "MY_UPSTREAMS_ADS_URL: \"https://ads.example.com\"")
+	assertStringContains(t, logged, "MY_UPSTREAMS_LEGACY_SEARCH_TIMEOUT_SECONDS: 30")
+	assertStringContains(t, logged, "MY_UPSTREAMS_PAYMENTS_URL: \"https://payments.example.com\"")
+}
+
+func TestNilStructPointers(t *testing.T) {
+	defer setEnv(t, "MY_NESTED_VALUE", "20")()
+	cfg := Config{}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	if cfg.Nested == nil {
+		t.Fatal("LoadWithPrefix() should allocate nil struct pointers")
+	}
+	assertIntsEqual(t, 20, cfg.Nested.Value)
+}
+
+type DefaultsConfig struct {
+	Port     int      `environment:"PORT" default:"8080"`
+	Hosts    []string `environment:"HOSTS" default:"a,b"`
+	Debug    bool     `environment:"DEBUG" default:"on"`
+	Nested   *Nested  `environment:"NESTED"`
+	Password string   `environment:"PASSWORD" required:"true"`
+}
+
+func TestGenericLoad(t *testing.T) {
+	defer setEnv(t, "MY_PORT", "80")()
+	defer setEnv(t, "MY_NESTED_VALUE", "3")()
+	defer setEnv(t, "MY_PASSWORD", "secret")()
+	cfg, err := configs.Load[DefaultsConfig]("MY")
+	if err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Port)
+	assertStringSlicesEqual(t, []string{"a", "b"}, cfg.Hosts)
+	assertBoolsEqual(t, true, cfg.Debug)
+	assertIntsEqual(t, 3, cfg.Nested.Value)
+	assertStringsEqual(t, "secret", cfg.Password)
+}
+
+func TestDefaultsDontOverwriteValues(t *testing.T) {
+	defer setEnv(t, "MY_PASSWORD", "secret")()
+	cfg := DefaultsConfig{
+		Port: 81,
+	}
+	if err := configs.LoadWithPrefix(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 81, cfg.Port)
+	assertStringSlicesEqual(t, []string{"a", "b"}, cfg.Hosts)
+}
+
+func TestRequiredValues(t *testing.T) {
+	_, err := configs.Load[DefaultsConfig]("MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_PASSWORD is required\n")
+}
+
+func TestStrictBoolsOption(t *testing.T) {
+	defer setEnv(t, "MY_DEBUG", "yes")()
+	defer setEnv(t, "MY_PASSWORD", "secret")()
+	_, err := configs.Load[DefaultsConfig]("MY", configs.StrictBools())
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `MY_DEBUG must be "true" or "false": got "yes"`)
+}
+
+func TestInvalidContainers(t *testing.T) {
+	var nilConfig *Config
+	containers := map[string]interface{}{
+		"got nil":                        nil,
+		"got a configs_test.Config":      Config{},
+		"got a nil *configs_test.Config": nilConfig,
+		"got a *int":                     new(int),
+	}
+	for expected, container := range containers {
+		err := configs.LoadWithPrefix(container, "MY")
+		if err == nil {
+			t.Errorf("Missing expected Load() error for %s", expected)
+			continue
+		}
+		assertStringContains(t, err.Error(), expected)
+	}
+	if _, err := configs.Load[int]("MY"); err == nil {
+		t.Error("Load[int]() should return an error")
+	}
+}
+
 func TestUndeflowingInts(t *testing.T) {
 	defer setEnv(t, "MY_UINT_8", "-1")()
 	defer setEnv(t, "MY_UINT_16", "-1")()
@@ -160,6 +552,26 @@ func TestOverflowingInts(t *testing.T) {
 	assertStringContains(t, msg, `MY_UINT_64 has a max value of 18446744073709551615: got "18446744073709551616"`)
 }
 
+func TestOutOfRangeInts(t *testing.T) {
+	defer setEnv(t, "MY_BIG", "9223372036854775808")()
+	defer setEnv(t, "MY_SMALL", "-9223372036854775809")()
+	defer setEnv(t, "MY_NEGATIVE", "-9223372036854775809")()
+	cfg := struct {
+		Big      int    `environment:"BIG"`
+		Small    int    `environment:"SMALL"`
+		Negative uint64 `environment:"NEGATIVE"`
+	}{}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, `MY_BIG has a max value of 9223372036854775807: got "9223372036854775808"`)
+	assertStringContains(t, msg, `MY_SMALL has a min value of -9223372036854775808: got "-9223372036854775809"`)
+	assertStringContains(t, msg, `MY_NEGATIVE has a min value of 0: got "-9223372036854775809"`)
+}
+
 func TestMultipleErrors(t *testing.T) {
 	defer setEnv(t, "MY_INT", "foo")()
 	cfg := Config{
@@ -259,6 +671,17 @@ func assertStringSlicesEqual(t *testing.T, expected []string, actual []string) {
 	}
 }
 
+func assertStringMapsEqual(t *testing.T, expected map[string]string, actual map[string]string) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Errorf(`Expected "%v" does not match actual "%v". The number of elements differ`, expected, actual)
+		return
+	}
+	for key, value := range expected {
+		assertStringsEqual(t, value, actual[key])
+	}
+}
+
 func assertIntSlicesEqual(t *testing.T, expected []int, actual []int) {
 	t.Helper()
 	if len(expected) != len(actual) {
@@ -0,0 +1,329 @@
+package configs
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// Loader loads values into structs, logs them, and describes them.
+// Its settings are shared by all of its methods.
+//
+// The zero value isn't usable. Create Loaders with NewLoader.
+type Loader struct {
+	source      Source
+	strictBools bool
+	separator   string
+	kvSeparator string
+	redactions  []string
+	logger      Logger
+	unknownKeys UnknownKeyPolicy
+	naming      keyNaming
+	expand      bool
+}
+
+// Option customizes a Loader.
+type Option func(*Loader)
+
+// Logger is where a Loader prints messages. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// standardLogger prints with the log package's standard logger.
+type standardLogger struct{}
+
+func (standardLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+// defaultLoader backs the package-level functions.
+var defaultLoader = NewLoader()
+
+// NewLoader returns a Loader which reads environment variables, unless
+// the options say otherwise.
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{
+		source:      Environment(),
+		separator:   ",",
+		kvSeparator: "=",
+		redactions:  []string{"password"},
+		logger:      standardLogger{},
+		naming:      defaultNaming,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithSource makes the Loader read values from src instead of the environment.
+func WithSource(src Source) Option {
+	return func(l *Loader) {
+		l.source = src
+	}
+}
+
+// StrictBools makes boolean fields only accept "true" and "false",
+// as if they were all tagged with strict:"true".
+func StrictBools() Option {
+	return func(l *Loader) {
+		l.strictBools = true
+	}
+}
+
+// WithSeparators changes the default separators used for slices and maps.
+// The "separator" and "kvseparator" tags still take precedence over these.
+func WithSeparators(separator string, kvSeparator string) Option {
+	return func(l *Loader) {
+		l.separator = separator
+		l.kvSeparator = kvSeparator
+	}
+}
+
+// WithRedactions marks keys which contain any of the patterns as secrets, in addition
+// to the ones containing "password". Patterns are case-insensitive.
+//
+// Fields tagged with secret:"true" are always secrets, and fields tagged with
+// secret:"false" never are.
+func WithRedactions(patterns ...string) Option {
+	return func(l *Loader) {
+		for _, pattern := range patterns {
+			l.redactions = append(l.redactions, strings.ToLower(pattern))
+		}
+	}
+}
+
+// WithLogger makes the Loader print messages with logger instead of the log package.
+func WithLogger(logger Logger) Option {
+	return func(l *Loader) {
+		l.logger = logger
+	}
+}
+
+// WithUnknownKeys decides what happens when the source has keys which start with the
+// prefix, but aren't used by the struct. These are often typos, like "MYAPP_PROT=80".
+// By default, they're ignored.
+func WithUnknownKeys(policy UnknownKeyPolicy) Option {
+	return func(l *Loader) {
+		l.unknownKeys = policy
+	}
+}
+
+// WithKeySeparator changes what goes between the prefix and each tag in a key.
+// By default, it's "_", as in "MYAPP_MAIN_PORT". The separator can't be empty.
+func WithKeySeparator(separator string) Option {
+	return func(l *Loader) {
+		if separator == "" {
+			panic("configs: the key separator can't be empty")
+		}
+		l.naming.separator = separator
+	}
+}
+
+// WithUpperCaseKeys converts the prefix and tags to upper case when building keys,
+// so that a field tagged environment:"port" loads from "MYAPP_PORT".
+func WithUpperCaseKeys() Option {
+	return func(l *Loader) {
+		l.naming.upperCase = true
+	}
+}
+
+// ExpandVariables makes the Loader expand references to other keys in every value, like
+// "https://${MYAPP_HOST}:${MYAPP_PORT}". Fields tagged with expand:"true" are expanded either way.
+//
+// "${KEY:-default}" uses default if KEY is unset or empty, and "$$" is a literal "$".
+// If a value references a secret, it's treated as a secret too.
+func ExpandVariables() Option {
+	return func(l *Loader) {
+		l.expand = true
+	}
+}
+
+// MustLoad works like Load, but panics if there's an error.
+func (l *Loader) MustLoad(container interface{}, prefix string) {
+	if err := l.Load(container, prefix); err != nil {
+		panic(err)
+	}
+}
+
+// Load loads values from the Loader's source into container, which must be a
+// pointer to a struct. It returns an error if any of the values don't match
+// the type defined on the struct.
+//
+// Loading is all-or-nothing. Values are loaded into a copy of the struct, which only
+// replaces the original if there weren't any errors.
+func (l *Loader) Load(container interface{}, prefix string) error {
+	if err := validateContainer(container); err != nil {
+		return err
+	}
+	copied := reflect.New(reflect.TypeOf(container).Elem())
+	copied.Elem().Set(deepCopy(reflect.ValueOf(container).Elem()))
+	if err := l.loadInPlace(copied.Interface(), prefix); err != nil {
+		return err
+	}
+	reflect.ValueOf(container).Elem().Set(copied.Elem())
+	return nil
+}
+
+// loadInPlace works like Load, but leaves any values it managed to load on container,
+// even if there were errors.
+func (l *Loader) loadInPlace(container interface{}, prefix string) error {
+	state := &loadState{}
+	if l.unknownKeys != IgnoreUnknownKeys {
+		state.known = make(map[string]struct{})
+	}
+	if err := validateContainer(container); err == nil {
+		if l.expand || analyzed(reflect.TypeOf(container).Elem(), l.naming).expands {
+			state.secrets = make(map[string]struct{})
+			state.references = make(map[string]map[string]struct{})
+		}
+	}
+	err := visit(container, prefix, l.loader(state))
+	if _, ok := err.(*traversalError); err == nil || ok {
+		err = l.checkUnknownKeys(err, prefix, state.known)
+	}
+	for key, referenced := range state.references {
+		if l.anySecret(referenced, state.secrets) {
+			if casted, ok := err.(*traversalError); ok {
+				casted.addSecret(key)
+			}
+		}
+	}
+	if casted, ok := err.(*traversalError); ok {
+		casted.source = l.source
+		casted.redactions = l.redactions
+	}
+	return err
+}
+
+// loadState holds what a Loader learns while loading one struct.
+type loadState struct {
+	// known holds every key which was looked up. It's nil unless unknown keys are being checked.
+	known map[string]struct{}
+	// secrets holds the keys of the secret fields. It's nil unless values might be expanded.
+	secrets map[string]struct{}
+	// references maps the key which each expanded value came from to the keys it referenced.
+	references map[string]map[string]struct{}
+}
+
+// Validate returns the same errors as Load, but doesn't change the container.
+func (l *Loader) Validate(container interface{}, prefix string) error {
+	if err := validateContainer(container); err != nil {
+		return err
+	}
+	copied := reflect.New(reflect.TypeOf(container).Elem())
+	copied.Elem().Set(deepCopy(reflect.ValueOf(container).Elem()))
+	return l.loadInPlace(copied.Interface(), prefix)
+}
+
+// CheckDefaults returns an error if any of the "default" tags on container's fields
+// can't be parsed. Defaults are normally only parsed when a key isn't set, so this
+// catches mistakes which would otherwise only show up in some environments.
+func (l *Loader) CheckDefaults(container interface{}, prefix string) error {
+	err := visit(container, prefix, visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			if !field.hasDefault {
+				return nil
+			}
+			if err := l.parseAndSet(field, reflect.New(value.Type()).Elem(), field.defaultValue); err != nil {
+				return &visitError{
+					error: fmt.Errorf("has an invalid default %q: %v", field.defaultValue, err),
+					Key:   environment,
+				}
+			}
+			return nil
+		},
+		naming: l.naming,
+	})
+	if casted, ok := err.(*traversalError); ok {
+		casted.source = l.source
+		casted.redactions = l.redactions
+	}
+	return err
+}
+
+// Log prints each key and its value on container, except for secrets.
+func (l *Loader) Log(container interface{}, prefix string) {
+	if err := visit(container, prefix, l.logVisitor(l.secretFields(container, prefix))); err != nil {
+		l.logger.Printf("%v", err)
+	}
+}
+
+// isSecret returns true if the value of key shouldn't be printed. secrets holds the keys
+// of the container's secret fields, from secretFields. A value which references any of
+// them is a secret too.
+func (l *Loader) isSecret(key string, field *fieldPlan, secrets map[string]struct{}) bool {
+	return l.referencesSecret(key, field, secrets) || l.isSecretField(key, field)
+}
+
+// isSecretField returns true if the field's tags or its key make it a secret,
+// regardless of its value.
+func (l *Loader) isSecretField(key string, field *fieldPlan) bool {
+	if field.hasSecret {
+		return field.secret
+	}
+	return matchesRedactions(key, l.redactions)
+}
+
+// secretFields returns the keys of the fields on container which are secrets by
+// isSecretField, along with their aliases.
+func (l *Loader) secretFields(container interface{}, prefix string) map[string]struct{} {
+	secrets := make(map[string]struct{})
+	visit(container, prefix, visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			l.addSecretField(secrets, environment, field)
+			return nil
+		},
+		naming: l.naming,
+	})
+	return secrets
+}
+
+// addSecretField adds key and the field's aliases to secrets, if the field is a secret.
+func (l *Loader) addSecretField(secrets map[string]struct{}, key string, field *fieldPlan) {
+	if !l.isSecretField(key, field) {
+		return
+	}
+	secrets[key] = struct{}{}
+	for _, fieldAlias := range field.aliases {
+		secrets[l.naming.aliasKey(key, field, fieldAlias)] = struct{}{}
+	}
+}
+
+// isSecretKey returns true if key holds a secret on container, or if it's one of the
+// aliases of a secret field. If container can't be visited, every key is treated as a secret.
+func (l *Loader) isSecretKey(container interface{}, prefix string, key string) bool {
+	secrets := l.secretFields(container, prefix)
+	secret := false
+	err := visit(container, prefix, visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			keys := []string{environment}
+			for _, fieldAlias := range field.aliases {
+				keys = append(keys, l.naming.aliasKey(environment, field, fieldAlias))
+			}
+			for _, fieldKey := range keys {
+				if fieldKey != key {
+					continue
+				}
+				value, _ := l.source.Lookup(key)
+				secret = l.isSecret(environment, field, secrets) || mightHavePassword(field.Type, value)
+			}
+			return nil
+		},
+		naming: l.naming,
+	})
+	return secret || err != nil
+}
+
+// matchesRedactions returns true if key contains any of the patterns, ignoring case.
+func matchesRedactions(key string, patterns []string) bool {
+	lower := strings.ToLower(key)
+	for _, pattern := range patterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package configs_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type LoaderConfig struct {
+	Hosts    []string       `environment:"HOSTS"`
+	Limits   map[string]int `environment:"LIMITS"`
+	APIToken string         `environment:"API_TOKEN"`
+	Hidden   string         `environment:"HIDDEN" secret:"true"`
+	Password string         `environment:"PASSWORD" secret:"false"`
+	Nested   *Nested        `environment:"NESTED"`
+}
+
+func TestLoaderOptions(t *testing.T) {
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_HOSTS":        "a;b",
+			"MY_LIMITS":       "free:1;paid:2",
+			"MY_API_TOKEN":    "abc123",
+			"MY_HIDDEN":       "shh",
+			"MY_PASSWORD":     "not-really",
+			"MY_NESTED_VALUE": "4",
+		})),
+		configs.WithSeparators(";", ":"),
+		configs.WithRedactions("TOKEN"),
+		configs.WithLogger(logger),
+	)
+
+	var cfg LoaderConfig
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertStringSlicesEqual(t, []string{"a", "b"}, cfg.Hosts)
+	assertIntsEqual(t, 2, cfg.Limits["paid"])
+	assertStringsEqual(t, "abc123", cfg.APIToken)
+	assertIntsEqual(t, 4, cfg.Nested.Value)
+
+	loader.Log(&cfg, "MY")
+	logged := logger.String()
+	assertStringContains(t, logged, "MY_API_TOKEN: <redacted>")
+	assertStringContains(t, logged, "MY_HIDDEN: <redacted>")
+	assertStringContains(t, logged, `MY_PASSWORD: "not-really"`)
+	assertStringContains(t, logged, "MY_NESTED_VALUE: 4")
+	assertNotStringContains(t, logged, "abc123")
+	assertNotStringContains(t, logged, "shh")
+}
+
+func TestLoaderErrorsUseSource(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_NESTED_VALUE": "four",
+			"MY_LIMITS":       "a=b",
+		})),
+	)
+	var cfg LoaderConfig
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `MY_NESTED_VALUE must be an int: got "four"`)
+	assertStringContains(t, err.Error(), `MY_LIMITS index 0 has an invalid value: must be an int: got "a=b"`)
+}
+
+func TestSecretsInErrors(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_SECRET_NUMBER": "12345x",
+		})),
+	)
+	cfg := struct {
+		SecretNumber int `environment:"SECRET_NUMBER" secret:"true"`
+	}{}
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_SECRET_NUMBER must be an int\n")
+	assertNotStringContains(t, err.Error(), "12345x")
+}
+
+func TestEnsureHidesSecrets(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_API_TOKEN":    "abc123",
+			"MY_HIDDEN":       "shh",
+			"MY_PASSWORD":     "not-really",
+			"MY_NESTED_VALUE": "4",
+		})),
+		configs.WithRedactions("TOKEN"),
+	)
+	var cfg LoaderConfig
+	err := loader.Load(&cfg, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	err = loader.Ensure(err, &cfg, "MY", "MY_API_TOKEN", false, "is too short")
+	err = loader.Ensure(err, &cfg, "MY", "MY_HIDDEN", false, "is too short")
+	err = loader.Ensure(err, &cfg, "MY", "MY_NESTED_VALUE", cfg.Nested.Value > 4, "must be more than 4")
+	if err == nil {
+		t.Error("Ensure() should have returned a real error")
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_API_TOKEN is too short\n")
+	assertStringContains(t, msg, "MY_HIDDEN is too short\n")
+	assertStringContains(t, msg, `MY_NESTED_VALUE must be more than 4: got "4"`)
+	assertNotStringContains(t, msg, "abc123")
+	assertNotStringContains(t, msg, "shh")
+}
+
+func TestValidateDoesntModify(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_HOSTS":        "a,b",
+			"MY_NESTED_VALUE": "4",
+		})),
+	)
+	cfg := LoaderConfig{
+		Hosts:  []string{"c"},
+		Nested: &Nested{Value: 1},
+	}
+	if err := loader.Validate(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Validate() error: %v", err)
+		return
+	}
+	assertStringSlicesEqual(t, []string{"c"}, cfg.Hosts)
+	assertIntsEqual(t, 1, cfg.Nested.Value)
+
+	loader = configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_NESTED_VALUE": "four",
+		})),
+	)
+	if err := loader.Validate(&cfg, "MY"); err == nil {
+		t.Error("Missing expected Validate() error")
+	}
+}
+
+func TestLoadIsAllOrNothing(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_HOSTS":        "a,b",
+			"MY_LIMITS":       "free=10",
+			"MY_NESTED_VALUE": "four",
+		})),
+	)
+	cfg := LoaderConfig{
+		Hosts:  []string{"c"},
+		Limits: map[string]int{"free": 1},
+	}
+	if err := loader.Load(&cfg, "MY"); err == nil {
+		t.Error("Missing expected Load() error")
+	}
+	assertStringSlicesEqual(t, []string{"c"}, cfg.Hosts)
+	assertIntsEqual(t, 1, cfg.Limits["free"])
+	if cfg.Nested != nil {
+		t.Errorf("Expected Nested to stay nil, but got %#v", cfg.Nested)
+	}
+}
+
+func TestLoaderDescribe(t *testing.T) {
+	descriptions, err := configs.NewLoader().Describe(&LoaderConfig{}, "MY")
+	if err != nil {
+		t.Errorf("Got unexpected Describe() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 7, len(descriptions))
+	assertStringsEqual(t, "MY_HOSTS", descriptions[0].Key)
+	assertStringsEqual(t, "[]string", descriptions[0].Type)
+	assertStringsEqual(t, "MY_NESTED_BIG_INT_POINTER", descriptions[6].Key)
+	assertStringsEqual(t, "*big.Int", descriptions[6].Type)
+}
+
+func TestConcurrentLoads(t *testing.T) {
+	type Concurrent struct {
+		Value  int     `environment:"VALUE"`
+		Nested *Nested `environment:"NESTED"`
+	}
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_VALUE":        "1",
+			"MY_NESTED_VALUE": "2",
+		})),
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var cfg Concurrent
+			if err := loader.Load(&cfg, "MY"); err != nil {
+				t.Errorf("Got unexpected Load() error: %v", err)
+				return
+			}
+			if cfg.Value != 1 || cfg.Nested.Value != 2 {
+				t.Errorf("Got unexpected values: %d and %d", cfg.Value, cfg.Nested.Value)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// bufferLogger is a configs.Logger which remembers everything it printed.
+type bufferLogger struct {
+	strings.Builder
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	l.WriteString(fmt.Sprintf(format, v...))
+	l.WriteString("\n")
+}
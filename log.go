@@ -1,39 +1,57 @@
 package configs
 
 import (
-	"log"
+	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 )
 
 // LogWithPrefix prints all the environment variables and their values on
 // container to stdout, excluding any which include the name "password" (for security)
 func LogWithPrefix(container interface{}, prefix string) {
-	visit(container, logger(prefix))
+	defaultLoader.Log(container, prefix)
 }
 
-// logger returns a Visitor that logs each value, except for ones with
-// "password" somewhere in the key,
+// logVisitor returns a Visitor that logs each value, except for secrets.
 //
 // This can be used to print config values on app startup, without
 // compromising any credentials.
-func logger(prefix string) visitor {
-	return visitor(func(environment string, value reflect.Value) *visitError {
-		logUnlessPassword(prefix+environment, value)
-		return nil
-	})
+func (l *Loader) logVisitor(secrets map[string]struct{}) visitor {
+	return visitor{
+		leaf: func(environment string, field *fieldPlan, value reflect.Value) *visitError {
+			l.logUnlessSecret(environment, field, value, secrets)
+			return nil
+		},
+		naming: l.naming,
+	}
 }
 
-func logUnlessPassword(environment string, value reflect.Value) {
-	if strings.Contains(strings.ToLower(environment), "password") {
-		log.Printf("%s: <redacted>", environment)
+func (l *Loader) logUnlessSecret(environment string, field *fieldPlan, value reflect.Value, secrets map[string]struct{}) {
+	if l.isSecret(environment, field, secrets) {
+		l.logger.Printf("%s: <redacted>", environment)
+	} else if field.format == jsonFormat {
+		l.logger.Printf("%s: %s", environment, l.redactedJSON(value))
+	} else if isBytes(value.Type()) || isNetworkType(value.Type()) {
+		l.logger.Printf("%s: %s", environment, l.display(field, value))
+	} else if stringer, ok := asStringer(value); ok {
+		l.logger.Printf("%s: %s", environment, stringer.String())
 	} else {
 		switch value.Kind() {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			log.Printf("%s: %s", environment, strconv.FormatUint(value.Uint(), 10))
+			l.logger.Printf("%s: %s", environment, strconv.FormatUint(value.Uint(), 10))
 		default:
-			log.Printf("%s: %#v", environment, value)
+			l.logger.Printf("%s: %#v", environment, value)
 		}
 	}
 }
+
+// asStringer returns the value as a fmt.Stringer if its type has a String method,
+// like ByteSize or Percent. Pointers and structs are excluded because %#v already
+// prints them well.
+func asStringer(value reflect.Value) (fmt.Stringer, bool) {
+	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Struct || !value.CanInterface() {
+		return nil, false
+	}
+	stringer, ok := value.Interface().(fmt.Stringer)
+	return stringer, ok
+}
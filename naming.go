@@ -0,0 +1,53 @@
+package configs
+
+import "strings"
+
+// keyNaming decides how keys are built from the prefix and the struct's tags.
+type keyNaming struct {
+	// separator goes between the prefix and each tag, like the "_" in "MYAPP_PORT".
+	separator string
+	// upperCase converts the prefix and tags to upper case.
+	upperCase bool
+}
+
+var defaultNaming = keyNaming{
+	separator: "_",
+}
+
+// root returns the key which a struct's fields should be joined onto.
+// Trailing separators are removed, so that "MYAPP" and "MYAPP_" produce the same keys.
+func (n keyNaming) root(prefix string) string {
+	for n.separator != "" && strings.HasSuffix(prefix, n.separator) {
+		prefix = strings.TrimSuffix(prefix, n.separator)
+	}
+	if n.upperCase {
+		return strings.ToUpper(prefix)
+	}
+	return prefix
+}
+
+// join adds a segment onto a key. If the key is empty, the segment is used on its own.
+func (n keyNaming) join(key string, segment string) string {
+	if key == "" {
+		return segment
+	}
+	return key + n.separator + segment
+}
+
+// aliasKey returns the key for one of the field's aliases, given the field's own key.
+// Aliases replace the field's last segment, so they share its parents.
+func (n keyNaming) aliasKey(key string, field *fieldPlan, a alias) string {
+	parent := strings.TrimSuffix(key, n.fieldName(field))
+	if n.upperCase {
+		return parent + a.upperName
+	}
+	return parent + a.name
+}
+
+// fieldName returns the segment which a field adds to the key.
+func (n keyNaming) fieldName(field *fieldPlan) string {
+	if n.upperCase {
+		return field.upperName
+	}
+	return field.name
+}
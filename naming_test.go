@@ -0,0 +1,222 @@
+package configs_test
+
+import (
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type LowerCaseConfig struct {
+	Main      Server              `environment:"main"`
+	Backends  []Backend           `environment:"backends"`
+	Upstreams map[string]Upstream `environment:"upstreams"`
+}
+
+func TestEmptyPrefix(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MAIN_PORT": "80",
+	})))
+	var cfg struct {
+		Main Server `environment:"MAIN"`
+	}
+	if err := loader.Load(&cfg, ""); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Main.Port)
+}
+
+func TestPrefixWithTrailingSeparator(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_MAIN_PORT": "80",
+	})))
+	var cfg struct {
+		Main Server `environment:"MAIN"`
+	}
+	if err := loader.Load(&cfg, "MY_"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Main.Port)
+}
+
+func TestCustomNaming(t *testing.T) {
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MYAPP.MAIN.PORT":            "80",
+			"MYAPP.BACKENDS.0.HOST":      "a.example.com",
+			"MYAPP.BACKENDS.1.PORT":      "81",
+			"MYAPP.UPSTREAMS.pay_v2.URL": "https://pay.example.com",
+			"MYAPP.UPSTREAMS.search.URL": "https://search.example.com",
+			"MYAPP_MAIN_PORT":            "90",
+		})),
+		configs.WithKeySeparator("."),
+		configs.WithUpperCaseKeys(),
+		configs.WithLogger(logger),
+	)
+	var cfg LowerCaseConfig
+	if err := loader.Load(&cfg, "myapp"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Main.Port)
+	assertIntsEqual(t, 2, len(cfg.Backends))
+	assertStringsEqual(t, "a.example.com", cfg.Backends[0].Host)
+	assertIntsEqual(t, 81, cfg.Backends[1].Port)
+	assertStringsEqual(t, "https://pay.example.com", cfg.Upstreams["pay_v2"].URL)
+	assertStringsEqual(t, "https://search.example.com", cfg.Upstreams["search"].URL)
+
+	loader.Log(&cfg, "myapp")
+	assertStringContains(t, logger.String(), "MYAPP.MAIN.PORT: 80")
+	assertStringContains(t, logger.String(), "MYAPP.UPSTREAMS.search.URL: \"https://search.example.com\"")
+}
+
+type AliasConfig struct {
+	DB struct {
+		Host string `environment:"HOST" aliases:"HOSTNAME,ADDRESS:deprecated"`
+	} `environment:"DB"`
+}
+
+func TestAliases(t *testing.T) {
+	sources := map[string]map[string]string{
+		"primary": {
+			"MY_DB_HOST":     "primary",
+			"MY_DB_HOSTNAME": "primary",
+		},
+		"alias": {
+			"MY_DB_HOSTNAME": "alias",
+		},
+		"deprecated": {
+			"MY_DB_ADDRESS": "deprecated",
+		},
+	}
+	for expected, values := range sources {
+		logger := &bufferLogger{}
+		loader := configs.NewLoader(
+			configs.WithSource(configs.MapSource(values)),
+			configs.WithLogger(logger),
+			configs.WithUnknownKeys(configs.RejectUnknownKeys),
+		)
+		var cfg AliasConfig
+		if err := loader.Load(&cfg, "MY"); err != nil {
+			t.Errorf("Got unexpected Load() error: %v", err)
+			continue
+		}
+		assertStringsEqual(t, expected, cfg.DB.Host)
+		if expected == "deprecated" {
+			assertStringContains(t, logger.String(), "MY_DB_ADDRESS is deprecated. Use MY_DB_HOST instead.")
+		} else {
+			assertStringsEqual(t, "", logger.String())
+		}
+	}
+}
+
+func TestConflictingAliases(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_DB_HOSTNAME": "a",
+			"MY_DB_ADDRESS":  "b",
+		})),
+		configs.WithLogger(&bufferLogger{}),
+	)
+	var cfg AliasConfig
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), "MY_DB_HOST is set to different values by MY_DB_HOSTNAME and MY_DB_ADDRESS\n")
+}
+
+func TestInvalidAliasValues(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_OLD_PORT":  "x",
+		"MY_OLD_TOKEN": "hunter2x",
+	})))
+	var cfg struct {
+		Port  int `environment:"PORT" aliases:"OLD_PORT"`
+		Token int `environment:"TOKEN" aliases:"OLD_TOKEN" secret:"true"`
+	}
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `MY_OLD_PORT must be an int: got "x"`)
+	assertStringContains(t, err.Error(), "MY_OLD_TOKEN must be an int\n")
+	assertNotStringContains(t, err.Error(), "MY_PORT")
+	assertNotStringContains(t, err.Error(), "hunter2x")
+}
+
+func TestCollidingAliases(t *testing.T) {
+	var cfg struct {
+		Host    string `environment:"HOST" aliases:"ADDRESS"`
+		Address string `environment:"ADDRESS"`
+		Port    int    `environment:"PORT" aliases:"bad-name"`
+	}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `.Host and .Address both use the key "ADDRESS"`)
+	assertStringContains(t, err.Error(), `.Port has the alias "bad-name", which can only contain letters, digits and underscores`)
+}
+
+type PlatformConfig struct {
+	Port int    `environment:"PORT" absolute:"true"`
+	Name string `environment:"NAME"`
+	AWS  struct {
+		Region string `environment:"AWS_REGION" absolute:"true"`
+		Bucket string `environment:"BUCKET"`
+	} `environment:"AWS"`
+	Kubernetes *struct {
+		Host string `environment:"HOST"`
+		Port int    `environment:"PORT"`
+	} `environment:"KUBERNETES_SERVICE" absolute:"true"`
+}
+
+func TestAbsoluteKeys(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"PORT":                    "8080",
+			"MY_NAME":                 "app",
+			"AWS_REGION":              "eu-west-1",
+			"MY_AWS_BUCKET":           "assets",
+			"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+			"KUBERNETES_SERVICE_PORT": "443",
+		})),
+		configs.WithUnknownKeys(configs.RejectUnknownKeys),
+	)
+	var cfg PlatformConfig
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 8080, cfg.Port)
+	assertStringsEqual(t, "app", cfg.Name)
+	assertStringsEqual(t, "eu-west-1", cfg.AWS.Region)
+	assertStringsEqual(t, "assets", cfg.AWS.Bucket)
+	assertStringsEqual(t, "10.0.0.1", cfg.Kubernetes.Host)
+	assertIntsEqual(t, 443, cfg.Kubernetes.Port)
+}
+
+func TestAbsoluteKeyProblems(t *testing.T) {
+	var cfg struct {
+		Port int `environment:"PORT" absolute:"true"`
+		HTTP struct {
+			Port int `environment:"PORT" absolute:"true"`
+		} `environment:"HTTP"`
+		Backends []struct {
+			Host string `environment:"HOST" absolute:"true"`
+		} `environment:"BACKENDS"`
+	}
+	err := configs.LoadWithPrefix(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	assertStringContains(t, err.Error(), `.Port and .HTTP.Port both use the key "PORT"`)
+	assertStringContains(t, err.Error(), `.Backends[].Host is absolute, but fields inside slices or maps of structs can't be`)
+}
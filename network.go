@@ -0,0 +1,370 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// HostPort is a host and port, like "example.com:443" or "[::1]:8080". It can be loaded from
+// any value which net.SplitHostPort accepts, as long as the port is a number.
+//
+// The host may be empty, as in ":8080", unless the field is tagged with require:"host".
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// String formats the pair like "example.com:443". IPv6 hosts are put in brackets.
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.Host, strconv.Itoa(int(h.Port)))
+}
+
+var urlType = reflect.TypeOf(url.URL{})
+var urlPtrType = reflect.TypeOf(&url.URL{})
+var ipType = reflect.TypeOf(net.IP{})
+var ipNetType = reflect.TypeOf(net.IPNet{})
+var ipNetPtrType = reflect.TypeOf(&net.IPNet{})
+var addrType = reflect.TypeOf(netip.Addr{})
+var prefixType = reflect.TypeOf(netip.Prefix{})
+var addrPortType = reflect.TypeOf(netip.AddrPort{})
+var hostPortType = reflect.TypeOf(HostPort{})
+
+// isNetworkType returns true if theType is one of the URL, IP or address types which
+// parseAndSetNetwork handles.
+func isNetworkType(theType reflect.Type) bool {
+	switch theType {
+	case urlType, urlPtrType, ipType, ipNetType, ipNetPtrType, addrType, prefixType, addrPortType, hostPortType:
+		return true
+	}
+	return false
+}
+
+// mightHavePassword returns true if value is for a field which holds URLs, and it looks like
+// it has a user in it. Logs and dumps replace the password with "xxxxx", but errors print
+// the value as it was set, so they leave it out entirely.
+func mightHavePassword(theType reflect.Type, value string) bool {
+	for theType.Kind() == reflect.Slice || theType.Kind() == reflect.Map {
+		theType = theType.Elem()
+	}
+	return (theType == urlType || theType == urlPtrType) && strings.Contains(value, "@")
+}
+
+// networkOptions come from the tags which validate network values.
+type networkOptions struct {
+	// requireScheme, requireHost and requirePort come from the "require" tag, like require:"scheme,host".
+	requireScheme bool
+	requireHost   bool
+	requirePort   bool
+	// schemes comes from the "schemes" tag, like schemes:"http,https". If it's not empty,
+	// URLs must use one of them.
+	schemes []string
+	// ipVersion comes from the "ipversion" tag. If it's 4 or 6, IP addresses must use that version.
+	ipVersion int
+}
+
+// networkOptionsFor reads the tags which validate network values. Invalid tags are ignored,
+// because networkTagProblem reports them before anything is loaded.
+func networkOptionsFor(field reflect.StructField) networkOptions {
+	var opts networkOptions
+	for _, part := range splitTag(field.Tag.Get("require")) {
+		switch part {
+		case "scheme":
+			opts.requireScheme = true
+		case "host":
+			opts.requireHost = true
+		case "port":
+			opts.requirePort = true
+		}
+	}
+	for _, scheme := range splitTag(field.Tag.Get("schemes")) {
+		opts.schemes = append(opts.schemes, strings.ToLower(scheme))
+	}
+	opts.ipVersion, _ = strconv.Atoi(field.Tag.Get("ipversion"))
+	return opts
+}
+
+// splitTag splits a comma-separated tag into its trimmed, non-empty parts.
+func splitTag(tag string) []string {
+	var parts []string
+	for _, part := range strings.Split(tag, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// networkTagProblem describes what's wrong with the field's "require", "schemes" and
+// "ipversion" tags, or returns "" if they're valid.
+func networkTagProblem(field reflect.StructField) string {
+	valueType := field.Type
+	if valueType.Kind() == reflect.Map || (valueType.Kind() == reflect.Slice && valueType != ipType) {
+		valueType = valueType.Elem()
+	}
+	isURL := valueType == urlType || valueType == urlPtrType
+	if tag, ok := field.Tag.Lookup("require"); ok {
+		if !isURL && valueType != hostPortType {
+			return "has a require tag, but only URLs and host:port pairs can have one"
+		}
+		for _, part := range splitTag(tag) {
+			if (part != "scheme" && part != "host" && part != "port") || (part == "scheme" && !isURL) {
+				return fmt.Sprintf("has require:%q, but %q isn't something it can require", tag, part)
+			}
+		}
+	}
+	if _, ok := field.Tag.Lookup("schemes"); ok && !isURL {
+		return "has a schemes tag, but only URLs can have one"
+	}
+	if tag, ok := field.Tag.Lookup("ipversion"); ok {
+		switch valueType {
+		case ipType, ipNetType, ipNetPtrType, addrType, prefixType, addrPortType:
+		default:
+			return "has an ipversion tag, but only IP addresses and CIDRs can have one"
+		}
+		if tag != "4" && tag != "6" {
+			return fmt.Sprintf(`has ipversion:%q, but the version must be "4" or "6"`, tag)
+		}
+	}
+	return ""
+}
+
+// parseAndSetNetwork parses value into toSet, whose type must be one that isNetworkType accepts.
+// An empty value sets the zero value, like it does for slices.
+func parseAndSetNetwork(toSet reflect.Value, value string, opts networkOptions) error {
+	if value == "" {
+		toSet.Set(reflect.Zero(toSet.Type()))
+		return nil
+	}
+	var parsed interface{}
+	var err error
+	switch toSet.Type() {
+	case urlType, urlPtrType:
+		parsed, err = parseURL(value, opts)
+	case ipType:
+		parsed, err = parseIP(value, opts.ipVersion)
+	case ipNetType, ipNetPtrType:
+		parsed, err = parseIPNet(value, opts.ipVersion)
+	case addrType:
+		parsed, err = parseAddr(value, opts.ipVersion)
+	case prefixType:
+		parsed, err = parsePrefix(value, opts.ipVersion)
+	case addrPortType:
+		parsed, err = parseAddrPort(value, opts.ipVersion)
+	case hostPortType:
+		parsed, err = parseHostPort(value, opts)
+	}
+	if err != nil {
+		return err
+	}
+	result := reflect.ValueOf(parsed)
+	if result.Type() != toSet.Type() {
+		// The parsers return pointers, but the field holds a value.
+		result = result.Elem()
+	}
+	toSet.Set(result)
+	return nil
+}
+
+func parseURL(value string, opts networkOptions) (*url.URL, error) {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		// url.Error repeats the whole value, which might have a password in it.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("must be a URL: %v", err)
+	}
+	switch {
+	case parsed.Scheme == "" && (opts.requireScheme || len(opts.schemes) > 0):
+		return nil, errors.New("must be a URL: missing scheme")
+	case len(opts.schemes) > 0 && !containsString(opts.schemes, parsed.Scheme):
+		return nil, fmt.Errorf("must be a URL: scheme must be one of %s", strings.Join(opts.schemes, ", "))
+	case parsed.Host == "" && opts.requireHost:
+		return nil, errors.New("must be a URL: missing host")
+	case parsed.Port() == "" && opts.requirePort:
+		return nil, errors.New("must be a URL: missing port")
+	}
+	if _, err := parsePort(parsed.Port()); parsed.Port() != "" && err != nil {
+		return nil, fmt.Errorf("must be a URL: %v", err)
+	}
+	return parsed, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePort parses a port number, which must fit in 16 bits.
+func parsePort(port string) (uint16, error) {
+	if port == "" {
+		return 0, errors.New("missing port")
+	}
+	parsed, err := strconv.ParseUint(port, 10, 16)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, errors.New("port out of range")
+	}
+	if err != nil {
+		return 0, errors.New("port must be a number")
+	}
+	return uint16(parsed), nil
+}
+
+// describeIP names the kind of IP address which ipVersion allows, for use in error messages.
+func describeIP(ipVersion int) string {
+	switch ipVersion {
+	case 4:
+		return "an IPv4 address"
+	case 6:
+		return "an IPv6 address"
+	default:
+		return "an IP address"
+	}
+}
+
+// checkIPVersion returns an error if addr isn't the version that ipVersion allows.
+// IPv4 addresses which are mapped into IPv6, like "::ffff:10.0.0.1", count as IPv4.
+func checkIPVersion(addr netip.Addr, ipVersion int) error {
+	addr = addr.Unmap()
+	if (ipVersion == 4 && !addr.Is4()) || (ipVersion == 6 && !addr.Is6()) {
+		return fmt.Errorf("must be %s", describeIP(ipVersion))
+	}
+	return nil
+}
+
+func parseAddr(value string, ipVersion int) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("must be %s", describeIP(ipVersion))
+	}
+	return addr, checkIPVersion(addr, ipVersion)
+}
+
+func parseIP(value string, ipVersion int) (net.IP, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("must be %s", describeIP(ipVersion))
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return ip, checkIPVersion(addr, ipVersion)
+}
+
+func parsePrefix(value string, ipVersion int) (netip.Prefix, error) {
+	description := "a CIDR like 10.0.0.0/8"
+	switch ipVersion {
+	case 4:
+		description = "an IPv4 CIDR like 10.0.0.0/8"
+	case 6:
+		description = "an IPv6 CIDR like fd00::/8"
+	}
+	addrPart, bitsPart, hasBits := strings.Cut(value, "/")
+	if !hasBits {
+		return netip.Prefix{}, fmt.Errorf("must be %s: missing prefix length", description)
+	}
+	addr, err := netip.ParseAddr(addrPart)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("must be %s: invalid IP address", description)
+	}
+	if checkIPVersion(addr, ipVersion) != nil {
+		return netip.Prefix{}, fmt.Errorf("must be %s", description)
+	}
+	bits, err := strconv.Atoi(bitsPart)
+	if err != nil || strconv.Itoa(bits) != bitsPart {
+		return netip.Prefix{}, fmt.Errorf("must be %s: prefix length must be a number", description)
+	}
+	if bits < 0 || bits > addr.BitLen() {
+		return netip.Prefix{}, fmt.Errorf("must be %s: prefix length out of range", description)
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("must be %s", description)
+	}
+	return prefix, nil
+}
+
+func parseIPNet(value string, ipVersion int) (*net.IPNet, error) {
+	// parsePrefix explains what's wrong better than net.ParseCIDR does.
+	if _, err := parsePrefix(value, ipVersion); err != nil {
+		return nil, err
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, errors.New("must be a CIDR like 10.0.0.0/8")
+	}
+	return ipNet, nil
+}
+
+func parseAddrPort(value string, ipVersion int) (netip.AddrPort, error) {
+	description := "must be " + describeIP(ipVersion) + " and port like 10.0.0.1:80"
+	host, port, err := splitHostPort(value)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("%s: %v", description, err)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || checkIPVersion(addr, ipVersion) != nil {
+		return netip.AddrPort{}, fmt.Errorf("%s: invalid IP address", description)
+	}
+	return netip.AddrPortFrom(addr, port), nil
+}
+
+func parseHostPort(value string, opts networkOptions) (HostPort, error) {
+	host, port, err := splitHostPort(value)
+	if err == nil && host == "" && opts.requireHost {
+		err = errors.New("missing host")
+	}
+	if err != nil {
+		return HostPort{}, fmt.Errorf("must be a host and port like example.com:80: %v", err)
+	}
+	return HostPort{Host: host, Port: port}, nil
+}
+
+// splitHostPort works like net.SplitHostPort, but parses the port too.
+func splitHostPort(value string) (string, uint16, error) {
+	host, portPart, err := net.SplitHostPort(value)
+	if err != nil {
+		// AddrError repeats the value, so just use its description.
+		var addrErr *net.AddrError
+		if errors.As(err, &addrErr) {
+			err = errors.New(strings.TrimSuffix(addrErr.Err, " in address"))
+		}
+		return "", 0, err
+	}
+	port, err := parsePort(portPart)
+	return host, port, err
+}
+
+// formatNetwork turns a value whose type isNetworkType accepts into a string which
+// parseAndSetNetwork would accept. If redact is true, passwords in URLs are replaced with "xxxxx".
+func formatNetwork(value reflect.Value, redact bool) string {
+	if value.IsZero() {
+		return ""
+	}
+	switch value.Type() {
+	case urlType:
+		copied := value.Interface().(url.URL)
+		if redact {
+			return copied.Redacted()
+		}
+		return copied.String()
+	case urlPtrType:
+		return formatNetwork(value.Elem(), redact)
+	case ipNetType:
+		copied := value.Interface().(net.IPNet)
+		return copied.String()
+	case ipNetPtrType:
+		return formatNetwork(value.Elem(), redact)
+	default:
+		return value.Interface().(fmt.Stringer).String()
+	}
+}
@@ -0,0 +1,196 @@
+package configs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldKind says how visit() treats a struct field.
+type fieldKind int
+
+const (
+	// leafField holds a value, which visitors act on.
+	leafField fieldKind = iota
+	// nestedField is a struct or a pointer to one, whose fields get visited.
+	nestedField
+	// structSliceField is a slice of structs, whose elements' fields get visited.
+	structSliceField
+	// structMapField is a map from strings to structs, whose elements' fields get visited.
+	structMapField
+	// skippedField doesn't have an environment tag, or has environment:"-".
+	// It isn't visited at all.
+	skippedField
+)
+
+// fieldPlan is everything that visit() and the visitors need to know about a struct field.
+//
+// Reading tags and building key segments with reflection isn't cheap, so each struct
+// type is only analyzed once. See planFor().
+type fieldPlan struct {
+	reflect.StructField
+	// name is this field's part of the key, like "PORT". It comes from the "environment" tag.
+	name string
+	// upperName is name in upper case.
+	upperName string
+	kind      fieldKind
+
+	// parse comes from the "strict" and "base" tags. Loaders may add to it.
+	parse parseOptions
+	// separator and kvSeparator come from their tags. If empty, the Loader's are used.
+	separator   string
+	kvSeparator string
+
+	defaultValue string
+	hasDefault   bool
+	required     bool
+
+	// expand comes from the "expand" tag. If true, references like ${KEY} in the field's
+	// value are expanded, even if the Loader doesn't do that for every field.
+	expand bool
+
+	// absolute comes from the "absolute" tag. If true, the field's key is just its name,
+	// without the prefix or its parents' names. This is meant for well-known keys like "PORT".
+	absolute bool
+
+	// format comes from the "format" tag. If it's "json", the field's value is loaded with
+	// encoding/json, and the field is a leaf even if it's a struct.
+	format string
+
+	// encoding comes from the "encoding" tag. It says how the values of []byte fields are
+	// encoded. If empty, they're base64.
+	encoding string
+
+	// description comes from the "desc" tag. It's only used by Describe.
+	description string
+
+	// aliases are other names for the field, in the order they should be tried.
+	// They come from the "aliases" tag.
+	aliases []alias
+
+	// secret comes from the "secret" tag, if hasSecret is true. []byte fields usually hold
+	// keys, so they're secrets unless they're tagged otherwise.
+	// If hasSecret is false, the key's name decides whether it's a secret.
+	secret    bool
+	hasSecret bool
+}
+
+// alias is another name for a field, like an old name which is being phased out.
+type alias struct {
+	name       string
+	upperName  string
+	deprecated bool
+}
+
+// structPlan describes the fields of a struct type.
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// plans caches a *structPlan for each reflect.Type which has been visited.
+var plans sync.Map
+
+// planFor returns the plan for theType, which must be a struct type.
+// It's safe to call from multiple goroutines.
+func planFor(theType reflect.Type) *structPlan {
+	if cached, ok := plans.Load(theType); ok {
+		return cached.(*structPlan)
+	}
+	plan, _ := plans.LoadOrStore(theType, buildPlan(theType))
+	return plan.(*structPlan)
+}
+
+func buildPlan(theType reflect.Type) *structPlan {
+	plan := &structPlan{
+		fields: make([]fieldPlan, theType.NumField()),
+	}
+	for i := 0; i < theType.NumField(); i++ {
+		plan.fields[i] = buildFieldPlan(theType.Field(i))
+	}
+	return plan
+}
+
+func buildFieldPlan(field reflect.StructField) fieldPlan {
+	name, tagged := field.Tag.Lookup("environment")
+	format := field.Tag.Get("format")
+	kind := kindOf(field.Type)
+	if format == jsonFormat {
+		kind = leafField
+	}
+	if !tagged || name == "-" {
+		kind = skippedField
+	}
+	plan := fieldPlan{
+		StructField: field,
+		name:        name,
+		upperName:   strings.ToUpper(name),
+		kind:        kind,
+		parse: parseOptions{
+			strictBools: field.Tag.Get("strict") == "true",
+			base:        10,
+		},
+		separator:   field.Tag.Get("separator"),
+		kvSeparator: field.Tag.Get("kvseparator"),
+		required:    field.Tag.Get("required") == "true",
+		absolute:    field.Tag.Get("absolute") == "true",
+		expand:      field.Tag.Get("expand") == "true",
+		format:      format,
+		encoding:    field.Tag.Get("encoding"),
+		description: field.Tag.Get("desc"),
+	}
+	if base, ok := baseTag(field); ok {
+		plan.parse.base = base
+	}
+	plan.parse.network = networkOptionsFor(field)
+	plan.defaultValue, plan.hasDefault = field.Tag.Lookup("default")
+	if tag := field.Tag.Get("aliases"); tag != "" {
+		for _, name := range strings.Split(tag, ",") {
+			name = strings.TrimSpace(name)
+			deprecated := strings.HasSuffix(name, ":deprecated")
+			name = strings.TrimSuffix(name, ":deprecated")
+			plan.aliases = append(plan.aliases, alias{
+				name:       name,
+				upperName:  strings.ToUpper(name),
+				deprecated: deprecated,
+			})
+		}
+	}
+	if tag, ok := field.Tag.Lookup("secret"); ok {
+		plan.secret = tag == "true"
+		plan.hasSecret = true
+	} else if isBytes(field.Type) {
+		plan.secret = true
+		plan.hasSecret = true
+	}
+	return plan
+}
+
+// baseTag parses the field's "base" tag. It returns false if the field doesn't have one,
+// or if it's invalid. Invalid tags are reported by analyze().
+func baseTag(field reflect.StructField) (int, bool) {
+	tag, ok := field.Tag.Lookup("base")
+	if !ok {
+		return 0, false
+	}
+	base, err := strconv.Atoi(tag)
+	if err != nil || base == 1 || base < 0 || base > 36 {
+		return 0, false
+	}
+	return base, true
+}
+
+func kindOf(theType reflect.Type) fieldKind {
+	switch {
+	case theType.Kind() == reflect.Ptr && theType.Elem().Kind() == reflect.Struct && !isTerminal(theType):
+		return nestedField
+	case theType.Kind() == reflect.Struct && !isTerminal(theType):
+		return nestedField
+	case isStructSlice(theType):
+		return structSliceField
+	case isStructMap(theType):
+		return structMapField
+	default:
+		return leafField
+	}
+}
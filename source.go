@@ -0,0 +1,67 @@
+package configs
+
+import (
+	"os"
+	"strings"
+)
+
+// Source supplies the values which get loaded into a struct.
+type Source interface {
+	// Lookup returns the value of key, and whether or not it was set.
+	Lookup(key string) (string, bool)
+	// Keys returns every key which has a value in this source.
+	Keys() []string
+}
+
+// Environment returns a Source backed by the process' environment variables.
+// This is the default Source for a Loader.
+func Environment() Source {
+	return environmentSource{}
+}
+
+type environmentSource struct{}
+
+func (environmentSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (environmentSource) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, keyValue := range environ {
+		keys = append(keys, strings.SplitN(keyValue, "=", 2)[0])
+	}
+	return keys
+}
+
+// keysWithPrefix returns the keys in src which start with prefix.
+func keysWithPrefix(src Source, prefix string) []string {
+	var keys []string
+	for _, key := range src.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// MapSource returns a Source backed by a map. It's useful in tests, or for values
+// which were read from somewhere other than the environment.
+func MapSource(values map[string]string) Source {
+	return mapSource(values)
+}
+
+type mapSource map[string]string
+
+func (m mapSource) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+func (m mapSource) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
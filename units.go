@@ -0,0 +1,173 @@
+package configs
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a number of bytes. It can be loaded from values like "512MiB" or "2GB".
+//
+// Units are case-insensitive. "KB", "MB", "GB", "TB", "PB" and "EB" are powers of 1000,
+// while "KiB", "MiB", "GiB", "TiB", "PiB" and "EiB" are powers of 1024.
+// Values without a unit (or with "B") are a number of bytes.
+type ByteSize uint64
+
+// Percent is a percentage, stored as a fraction. It can be loaded from values
+// like "75%", which would be stored as 0.75.
+type Percent float64
+
+var byteSizeType = reflect.TypeOf(ByteSize(0))
+var percentType = reflect.TypeOf(Percent(0))
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// byteSizeUnits are ordered from largest to smallest, so that String() uses the biggest unit it can.
+var byteSizeUnits = []struct {
+	name  string
+	bytes uint64
+}{
+	{"EiB", 1 << 60},
+	{"EB", 1e18},
+	{"PiB", 1 << 50},
+	{"PB", 1e15},
+	{"TiB", 1 << 40},
+	{"TB", 1e12},
+	{"GiB", 1 << 30},
+	{"GB", 1e9},
+	{"MiB", 1 << 20},
+	{"MB", 1e6},
+	{"KiB", 1 << 10},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+// String formats the size with the largest unit which divides it evenly, like "512MiB".
+func (b ByteSize) String() string {
+	for _, unit := range byteSizeUnits {
+		if b != 0 && uint64(b)%unit.bytes == 0 {
+			return strconv.FormatUint(uint64(b)/unit.bytes, 10) + unit.name
+		}
+	}
+	return "0B"
+}
+
+// String formats the percentage like "75%". Parsing the result gives back exactly p.
+func (p Percent) String() string {
+	if math.IsNaN(float64(p)) || math.IsInf(float64(p), 0) {
+		return strconv.FormatFloat(float64(p), 'f', -1, 64) + "%"
+	}
+	// Multiplying by 100 would round, so shift the decimal point of p's shortest representation instead.
+	shortest := strconv.FormatFloat(float64(p), 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(shortest, "e")
+	power, _ := strconv.Atoi(exponent)
+	fractionDigits := len(strings.TrimLeft(strings.Replace(mantissa, ".", "", 1), "-")) - 1 - (power + 2)
+	if fractionDigits < 0 {
+		fractionDigits = 0
+	}
+	exact, _ := new(big.Rat).SetString(shortest)
+	return exact.Mul(exact, big.NewRat(100, 1)).FloatString(fractionDigits) + "%"
+}
+
+func parseAndSetByteSize(toSet reflect.Value, value string) error {
+	parsed, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	toSet.SetUint(uint64(parsed))
+	return nil
+}
+
+func parseByteSize(value string) (ByteSize, error) {
+	trimmed := strings.TrimSpace(value)
+	number := strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	})
+	unitName := strings.TrimSpace(trimmed[len(number):])
+	multiplier := uint64(1)
+	if unitName != "" {
+		multiplier = 0
+		for _, unit := range byteSizeUnits {
+			if strings.EqualFold(unit.name, unitName) {
+				multiplier = unit.bytes
+			}
+		}
+	}
+	// Use a big.Rat so that values like "1.5GB" are exact.
+	amount, ok := parseDecimal(strings.TrimSpace(number))
+	if multiplier == 0 || !ok || amount.Sign() < 0 {
+		return 0, errors.New("must be a size like 512MiB or 2GB")
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).SetUint64(multiplier)))
+	if !amount.IsInt() {
+		return 0, errors.New("must be a whole number of bytes")
+	}
+	if !amount.Num().IsUint64() {
+		return 0, errors.New("has a max value of " + strconv.FormatUint(math.MaxUint64, 10) + " bytes")
+	}
+	return ByteSize(amount.Num().Uint64()), nil
+}
+
+func parseAndSetPercent(toSet reflect.Value, value string) error {
+	parsed, err := parsePercent(value)
+	if err != nil {
+		return err
+	}
+	toSet.SetFloat(float64(parsed))
+	return nil
+}
+
+func parsePercent(value string) (Percent, error) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasSuffix(trimmed, "%") {
+		return 0, errors.New("must be a percentage like 75%")
+	}
+	number := strings.TrimSpace(strings.TrimSuffix(trimmed, "%"))
+	// Use a big.Rat so that dividing by 100 only rounds once.
+	amount, ok := parseDecimal(number)
+	if !ok {
+		return 0, errors.New("must be a percentage like 75%")
+	}
+	parsed, _ := amount.Quo(amount, big.NewRat(100, 1)).Float64()
+	if math.IsInf(parsed, 0) {
+		return 0, errors.New("must be a percentage like 75%")
+	}
+	return Percent(parsed), nil
+}
+
+func parseAndSetDuration(toSet reflect.Value, value string) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return errors.New("must be a duration like 1m30s or 250ms")
+	}
+	toSet.SetInt(int64(parsed))
+	return nil
+}
+
+// maxDecimalExponent is the largest exponent which parseDecimal accepts. It's well past the
+// range of a float64 or uint64, but small enough that a big.Rat can hold the number quickly.
+const maxDecimalExponent = 400
+
+// parseDecimal parses a number like "-1.5" or "2e3" exactly. Unlike big.Rat's SetString,
+// it doesn't accept fractions, other bases, or exponents so big that they'd use up all the memory.
+func parseDecimal(value string) (*big.Rat, bool) {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(value), "e")
+	digits := strings.TrimLeft(mantissa, "+-")
+	if len(mantissa)-len(digits) > 1 {
+		return nil, false
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole+fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return nil, false
+	}
+	if hasExponent {
+		power, err := strconv.Atoi(exponent)
+		if err != nil || power > maxDecimalExponent || power < -maxDecimalExponent {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(value)
+}
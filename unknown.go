@@ -0,0 +1,90 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// UnknownKeyPolicy decides what a Loader does with unknown keys. See WithUnknownKeys.
+type UnknownKeyPolicy int
+
+const (
+	// IgnoreUnknownKeys doesn't check for unknown keys at all.
+	IgnoreUnknownKeys UnknownKeyPolicy = iota
+	// WarnOnUnknownKeys prints a message about each unknown key with the Loader's Logger.
+	WarnOnUnknownKeys
+	// RejectUnknownKeys makes Load return an error for each unknown key.
+	RejectUnknownKeys
+)
+
+// checkUnknownKeys reports any keys in the Loader's source which start with the prefix,
+// but aren't in known. Errors are added to errs.
+func (l *Loader) checkUnknownKeys(errs error, prefix string, known map[string]struct{}) error {
+	// Without a prefix, every key in the environment would be reported.
+	root := l.naming.root(prefix)
+	if known == nil || root == "" {
+		return errs
+	}
+	unknown := keysWithPrefix(l.source, root+l.naming.separator)
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		if _, ok := known[key]; ok {
+			continue
+		}
+		msg := "isn't used by the config"
+		if suggestion := closestKey(key, known); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %s?)", suggestion)
+		}
+		if l.unknownKeys == WarnOnUnknownKeys {
+			l.logger.Printf("%s %s", key, msg)
+		} else {
+			// Unknown keys are often misspelled secrets, so their values are never printed.
+			errs = appendError(errs, key, errors.New(msg))
+			errs.(*traversalError).addSecret(key)
+		}
+	}
+	return errs
+}
+
+// closestKey returns the key in candidates with the smallest edit distance from key.
+// If none of them are close enough to be a likely typo, it returns "".
+func closestKey(key string, candidates map[string]struct{}) string {
+	best := ""
+	bestDistance := len(key)/3 + 1
+	for candidate := range candidates {
+		distance := editDistance(key, candidate)
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
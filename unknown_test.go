@@ -0,0 +1,94 @@
+package configs_test
+
+import (
+	"testing"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type Server struct {
+	Port int `environment:"PORT"`
+}
+
+var unknownValues = map[string]string{
+	"MY_MAIN_PORT":       "80",
+	"MY_MAIN_PROT":       "81",
+	"MY_BACKENDS_0_HSOT": "a.example.com",
+	"MY_SOMETHING_ELSE":  "x",
+	"OTHER_MAIN_PROT":    "82",
+}
+
+func TestRejectUnknownKeys(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(unknownValues)),
+		configs.WithUnknownKeys(configs.RejectUnknownKeys),
+	)
+	var cfg struct {
+		Main     *Server   `environment:"MAIN"`
+		Backends []Backend `environment:"BACKENDS"`
+	}
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_MAIN_PROT isn't used by the config (did you mean MY_MAIN_PORT?)\n")
+	assertStringContains(t, msg, "MY_BACKENDS_0_HSOT isn't used by the config (did you mean MY_BACKENDS_0_HOST?)\n")
+	assertStringContains(t, msg, "MY_SOMETHING_ELSE isn't used by the config\n")
+	assertNotStringContains(t, msg, "OTHER_MAIN_PROT")
+	assertNotStringContains(t, msg, "MY_MAIN_PORT isn't")
+}
+
+func TestUnknownKeysHideValues(t *testing.T) {
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(map[string]string{
+			"MY_DB_PASWORD": "hunter2",
+			"MY_HMAC_KY":    "c2VjcmV0",
+		})),
+		configs.WithUnknownKeys(configs.RejectUnknownKeys),
+	)
+	var cfg struct {
+		DBPassword string `environment:"DB_PASSWORD"`
+		HMACKey    []byte `environment:"HMAC_KEY"`
+	}
+	err := loader.Load(&cfg, "MY")
+	if err == nil {
+		t.Errorf("Missing expected Load() error: %v", err)
+		return
+	}
+	msg := err.Error()
+	assertStringContains(t, msg, "MY_DB_PASWORD isn't used by the config (did you mean MY_DB_PASSWORD?)\n")
+	assertStringContains(t, msg, "MY_HMAC_KY isn't used by the config (did you mean MY_HMAC_KEY?)\n")
+	assertNotStringContains(t, msg, "hunter2")
+	assertNotStringContains(t, msg, "c2VjcmV0")
+}
+
+func TestWarnOnUnknownKeys(t *testing.T) {
+	logger := &bufferLogger{}
+	loader := configs.NewLoader(
+		configs.WithSource(configs.MapSource(unknownValues)),
+		configs.WithUnknownKeys(configs.WarnOnUnknownKeys),
+		configs.WithLogger(logger),
+	)
+	var cfg struct {
+		Main *Server `environment:"MAIN"`
+	}
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+		return
+	}
+	assertIntsEqual(t, 80, cfg.Main.Port)
+	assertStringContains(t, logger.String(), "MY_MAIN_PROT isn't used by the config (did you mean MY_MAIN_PORT?)\n")
+	assertStringContains(t, logger.String(), "MY_BACKENDS_0_HSOT isn't used by the config\n")
+}
+
+func TestIgnoreUnknownKeysByDefault(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(unknownValues)))
+	var cfg struct {
+		Main *Server `environment:"MAIN"`
+	}
+	if err := loader.Load(&cfg, "MY"); err != nil {
+		t.Errorf("Got unexpected Load() error: %v", err)
+	}
+}
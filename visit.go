@@ -1,14 +1,33 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-// visitor is a function which acts on struct leaf properties.
-type visitor func(environment string, value reflect.Value) *visitError
+// visitor acts on the properties of a struct.
+type visitor struct {
+	// leaf is called on each property which holds a value, rather than more properties.
+	// field describes the leaf's definition on its parent struct, including its tags.
+	leaf func(environment string, field *fieldPlan, value reflect.Value) *visitError
+
+	// collection is optional. If set, it's called on each slice or map of structs
+	// before its elements are visited. This gives visitors a chance to resize it.
+	collection func(environment string, field *fieldPlan, value reflect.Value) *visitError
+
+	// naming decides how the keys passed to the other functions are built.
+	// If its separator is empty, the default naming is used.
+	naming keyNaming
+
+	// modifies should be true if the visitor changes the values it visits.
+	// If so, nil struct pointers will be allocated before their properties are visited,
+	// and map elements will be stored again after theirs are.
+	modifies bool
+}
 
 // visitError is an error which can be returned by Visitors if something
 // went wrong while running the function.
@@ -17,65 +36,187 @@ type visitError struct {
 	// Key describes the leaf node. In general, this can just be the
 	// "environment" argument.
 	Key string
+	// Secret should be true if the value at Key must not be printed in error messages.
+	Secret bool
 }
 
 // visit calls the visitor function on each property on container,
 // unless that property is a struct itself. It will recurse through any
 // any structs until it eventually gets finds the leaves.
-func visit(container interface{}, v visitor) error {
-	return doVisit("", reflect.ValueOf(container), v, nil)
+//
+// Slices and maps of structs are recursed into as well. The environment of each
+// element's properties includes its index or key, like "_BACKENDS_0_HOST" or
+// "_UPSTREAMS_PAYMENTS_URL".
+//
+// The environment passed to the visitor functions starts with prefix.
+func visit(container interface{}, prefix string, v visitor) error {
+	if err := validateContainer(container); err != nil {
+		return err
+	}
+	if v.naming.separator == "" {
+		v.naming = defaultNaming
+	}
+	if err := analyze(reflect.TypeOf(container).Elem(), v.naming); err != nil {
+		return err
+	}
+	return doVisit(v.naming.root(prefix), reflect.ValueOf(container), v, nil)
+}
+
+// validateContainer returns an error unless container is a non-nil pointer to a struct.
+func validateContainer(container interface{}) error {
+	if container == nil {
+		return errors.New("configs: container must be a non-nil pointer to a struct, but got nil")
+	}
+	value := reflect.ValueOf(container)
+	if value.Kind() != reflect.Ptr || value.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("configs: container must be a non-nil pointer to a struct, but got a %v", value.Type())
+	}
+	if value.IsNil() {
+		return fmt.Errorf("configs: container must be a non-nil pointer to a struct, but got a nil %v", value.Type())
+	}
+	return nil
 }
 
 var s struct{}
 var terminalTypes = map[string]struct{}{
-	"big.Int":  s,
-	"*big.Int": s,
+	"big.Int":          s,
+	"*big.Int":         s,
+	"url.URL":          s,
+	"*url.URL":         s,
+	"net.IPNet":        s,
+	"*net.IPNet":       s,
+	"netip.Addr":       s,
+	"netip.Prefix":     s,
+	"netip.AddrPort":   s,
+	"configs.HostPort": s,
 }
 
 func doVisit(environmentSoFar string, theValue reflect.Value, v visitor, errs error) error {
 	theType := theValue.Type().Elem()
+	if theValue.IsNil() {
+		if v.modifies {
+			theValue.Set(reflect.New(theType))
+		} else {
+			theValue = reflect.New(theType)
+		}
+	}
 
-	for i := 0; i < theType.NumField(); i++ {
-		thisField := theType.Field(i)
+	plan := planFor(theType)
+	for i := range plan.fields {
+		thisField := &plan.fields[i]
 		thisFieldValue := theValue.Elem().Field(i)
-		environment := environmentSoFar + "_" + thisField.Tag.Get("environment")
-		switch thisField.Type.Kind() {
-		case reflect.Ptr:
-			if _, ok := terminalTypes[thisField.Type.String()]; ok {
-				if err := v(environment, thisFieldValue); err != nil {
-					errs = appendError(errs, err.Key, err)
-				}
-			} else {
-				errs = doVisit(environment, thisFieldValue, v, errs)
+		environment := v.naming.join(environmentSoFar, v.naming.fieldName(thisField))
+		if thisField.absolute {
+			environment = v.naming.fieldName(thisField)
+		}
+		switch thisField.kind {
+		case skippedField:
+		case nestedField:
+			if thisFieldValue.Kind() == reflect.Struct {
+				thisFieldValue = thisFieldValue.Addr()
 			}
+			errs = doVisit(environment, thisFieldValue, v, errs)
+		case structSliceField:
+			errs = visitStructSlice(environment, thisField, thisFieldValue, v, errs)
+		case structMapField:
+			errs = visitStructMap(environment, thisField, thisFieldValue, v, errs)
 		default:
-			if err := v(environment, thisFieldValue); err != nil {
-				errs = appendError(errs, err.Key, err)
+			if err := v.leaf(environment, thisField, thisFieldValue); err != nil {
+				errs = appendVisitError(errs, err)
 			}
 		}
 	}
 	return errs
 }
 
+// isTerminal returns true if theType should be treated as a single value,
+// even though it's implemented with a struct.
+func isTerminal(theType reflect.Type) bool {
+	_, ok := terminalTypes[theType.String()]
+	return ok
+}
+
+// isStructSlice returns true if theType is a slice whose elements have properties
+// of their own, rather than being values.
+func isStructSlice(theType reflect.Type) bool {
+	return theType.Kind() == reflect.Slice && theType.Elem().Kind() == reflect.Struct && !isTerminal(theType.Elem())
+}
+
+// isStructMap returns true if theType is a map from strings to values which have
+// properties of their own.
+func isStructMap(theType reflect.Type) bool {
+	return theType.Kind() == reflect.Map &&
+		theType.Key().Kind() == reflect.String &&
+		theType.Elem().Kind() == reflect.Struct &&
+		!isTerminal(theType.Elem())
+}
+
+func visitStructSlice(environment string, field *fieldPlan, value reflect.Value, v visitor, errs error) error {
+	if v.collection != nil {
+		if err := v.collection(environment, field, value); err != nil {
+			return appendVisitError(errs, err)
+		}
+	}
+	for i := 0; i < value.Len(); i++ {
+		errs = doVisit(v.naming.join(environment, strconv.Itoa(i)), value.Index(i).Addr(), v, errs)
+	}
+	return errs
+}
+
+func visitStructMap(environment string, field *fieldPlan, value reflect.Value, v visitor, errs error) error {
+	if v.collection != nil {
+		if err := v.collection(environment, field, value); err != nil {
+			return appendVisitError(errs, err)
+		}
+	}
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, key := range keys {
+		// Map elements aren't addressable, so visit a copy instead.
+		elem := reflect.New(value.Type().Elem())
+		elem.Elem().Set(value.MapIndex(key))
+		errs = doVisit(v.naming.join(environment, key.String()), elem, v, errs)
+		if v.modifies {
+			value.SetMapIndex(key, elem.Elem())
+		}
+	}
+	return errs
+}
+
 // traversalError is returned by visit() if the visitor returned any errors
 type traversalError struct {
 	summary     string
 	invalidKeys map[string]error
+	// source is where the invalid values came from. If nil, they came from
+	// the environment.
+	source Source
+	// redactions are patterns which mark keys as secret. If nil, only keys
+	// containing "password" are secret.
+	redactions []string
+	// secretKeys are keys which are secret regardless of their names.
+	secretKeys map[string]struct{}
 }
 
 // Ensure adds custom error messagse to the error returned by LoadWithPrefix().
 //
 // Normal usage looks like this:
 //
-//   err := configs.LoadWithPrefix(&cfg, "MYAPP")
-//   err = configs.Ensure(err, "MYAPP_PORT", cfg.Port > 0, "must be a positive integer")
-//   err = configs.Ensure(err, "MYAPP_ENV", isValid(cfg.ENV), "must be one of: %v", validEnvs)
+//	err := configs.LoadWithPrefix(&cfg, "MYAPP")
+//	err = configs.Ensure(err, "MYAPP_PORT", cfg.Port > 0, "must be a positive integer")
+//	err = configs.Ensure(err, "MYAPP_ENV", isValid(cfg.ENV), "must be one of: %v", validEnvs)
 //
 // If predicate is true, err is returned unchanged.
 // If predicate is false and err is nil, a new error will be returned.
+// Errors which mean the struct couldn't be loaded at all, like invalid tags,
+// are also returned unchanged.
 //
 // In all cases the returned error will "pretty print" your validation error alongside
 // any errors generated by the LoadWithPrefix() call.
+//
+// Ensure only knows that keys containing "password" are secrets, unless err came from a
+// failed Load. Use Loader.Ensure if the config has other secrets.
 func Ensure(err error, key string, predicate bool, msgFormat string, msgArgs ...interface{}) error {
 	if predicate {
 		return err
@@ -83,6 +224,38 @@ func Ensure(err error, key string, predicate bool, msgFormat string, msgArgs ...
 	return appendError(err, key, fmt.Errorf(msgFormat, msgArgs...))
 }
 
+// Ensure works like the package-level Ensure, but keeps the value of key out of the message
+// if it's a secret on container, by the same rules as Log. Values are read from the
+// Loader's source.
+//
+//	err := loader.Load(&cfg, "MYAPP")
+//	err = loader.Ensure(err, &cfg, "MYAPP", "MYAPP_API_TOKEN", len(cfg.APIToken) == 40, "must have 40 characters")
+func (l *Loader) Ensure(err error, container interface{}, prefix string, key string, predicate bool, msgFormat string, msgArgs ...interface{}) error {
+	if predicate {
+		return err
+	}
+	err = appendError(err, key, fmt.Errorf(msgFormat, msgArgs...))
+	casted, ok := err.(*traversalError)
+	if !ok {
+		return err
+	}
+	casted.source = l.source
+	casted.redactions = l.redactions
+	if l.isSecretKey(container, prefix, key) {
+		casted.addSecret(key)
+	}
+	return err
+}
+
+// appendVisitError works like appendError, but also remembers if the key holds a secret.
+func appendVisitError(errs error, err *visitError) error {
+	errs = appendError(errs, err.Key, err)
+	if err.Secret {
+		errs.(*traversalError).addSecret(err.Key)
+	}
+	return errs
+}
+
 func appendError(err error, key string, msg error) error {
 	if err == nil {
 		return &traversalError{
@@ -103,7 +276,17 @@ func appendError(err error, key string, msg error) error {
 		return casted
 	}
 
-	panic("Ensure() only works on errors returend by this library")
+	// Other errors, like the ones from analyze(), mean that nothing was loaded.
+	// Validation errors wouldn't add anything useful to them.
+	return err
+}
+
+// addSecret makes sure that the value of key won't be printed.
+func (p *traversalError) addSecret(key string) {
+	if p.secretKeys == nil {
+		p.secretKeys = make(map[string]struct{})
+	}
+	p.secretKeys[key] = struct{}{}
 }
 
 // Error returns an error message describing all the invalid environment variables.
@@ -112,16 +295,34 @@ func (p *traversalError) Error() string {
 		return ""
 	}
 
+	var src Source = environmentSource{}
+	if p.source != nil {
+		src = p.source
+	}
+	redactions := p.redactions
+	if redactions == nil {
+		redactions = []string{"password"}
+	}
+
+	keys := make([]string, 0, len(p.invalidKeys))
+	for env := range p.invalidKeys {
+		keys = append(keys, env)
+	}
+	sort.Strings(keys)
+
 	msg := strings.Builder{}
 	msg.WriteString("Errors occurred while acting on the struct:\n")
-	for env, err := range p.invalidKeys {
+	for _, env := range keys {
+		err := p.invalidKeys[env]
 		// May be overkill... but playing it a little safe. Someone might mis-type a password,
 		// call Ensure() after a failed login, and then this library would print a password
 		// that's only off by one character.
-		if strings.Contains(strings.ToLower(env), "password") {
+		_, isSecret := p.secretKeys[env]
+		value, isSet := src.Lookup(env)
+		if !isSet || isSecret || matchesRedactions(env, redactions) {
 			msg.WriteString(fmt.Sprintf("  %s %v\n", env, err))
 		} else {
-			msg.WriteString(fmt.Sprintf("  %s %v: got \"%s\"\n", env, err, os.Getenv(env)))
+			msg.WriteString(fmt.Sprintf("  %s %v: got \"%s\"\n", env, err, value))
 		}
 	}
 	return msg.String()
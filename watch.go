@@ -0,0 +1,220 @@
+package configs
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WatchOption customizes a Watcher.
+type WatchOption func(*watchSettings)
+
+type watchSettings struct {
+	interval time.Duration
+	signals  []os.Signal
+	files    []string
+}
+
+// PollInterval changes how often a Watcher checks its files for changes. The default is 2 seconds.
+func PollInterval(interval time.Duration) WatchOption {
+	return func(s *watchSettings) {
+		s.interval = interval
+	}
+}
+
+// ReloadOnSignals changes which signals make a Watcher reload. By default, it's just SIGHUP.
+// With no arguments, signals are ignored.
+func ReloadOnSignals(signals ...os.Signal) WatchOption {
+	return func(s *watchSettings) {
+		s.signals = signals
+	}
+}
+
+// WatchFiles makes a Watcher reload when any of the files at paths change, in addition to
+// the files of the Loader's source. This is useful if the source reads files itself.
+func WatchFiles(paths ...string) WatchOption {
+	return func(s *watchSettings) {
+		s.files = append(s.files, paths...)
+	}
+}
+
+// reloader is a Source which can read its values again, like a FileSource.
+type reloader interface {
+	Reload() error
+}
+
+// fileSource is a Source whose values come from files, like a FileSource.
+type fileSource interface {
+	Files() []string
+}
+
+// Watcher holds a config of type T, and reloads it when its files change or the process
+// gets a signal. Each new config is loaded and validated in full before it replaces the old one,
+// so a bad edit leaves the old config in use.
+//
+// Watchers are safe to use from multiple goroutines.
+type Watcher[T any] struct {
+	loader   *Loader
+	prefix   string
+	settings watchSettings
+
+	current Holder[T]
+
+	// mu makes sure that only one reload runs at a time, and guards the fields below.
+	mu          sync.Mutex
+	subscribers []func(cfg T, changes []Change)
+	stamps      map[string]fileStamp
+
+	signals   chan os.Signal
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// fileStamp is what a Watcher checks to see whether a file has changed.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// Watch loads a T with loader, and keeps reloading it until the Watcher is closed.
+// It returns an error if the first load fails.
+//
+// If the loader's source has a Reload method, like a FileSource, it's called before each reload.
+func Watch[T any](loader *Loader, prefix string, opts ...WatchOption) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		loader: loader,
+		prefix: prefix,
+		settings: watchSettings{
+			interval: 2 * time.Second,
+			signals:  []os.Signal{syscall.SIGHUP},
+		},
+		stamps:  make(map[string]fileStamp),
+		signals: make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&w.settings)
+	}
+	if files, ok := loader.source.(fileSource); ok {
+		w.settings.files = append(w.settings.files, files.Files()...)
+	}
+	w.filesChanged()
+
+	var first T
+	if err := loader.Load(&first, prefix); err != nil {
+		return nil, err
+	}
+	w.current.Set(first)
+	// Start listening before returning, so that no signals get missed.
+	if len(w.settings.signals) > 0 {
+		signal.Notify(w.signals, w.settings.signals...)
+	}
+	go w.run()
+	return w, nil
+}
+
+// Get returns the current config. Don't modify any slices, maps or pointers on it,
+// because they're shared with other callers.
+func (w *Watcher[T]) Get() T {
+	return w.current.Get()
+}
+
+// Subscribe makes the Watcher call fn after each reload which changes any values.
+// fn gets the new config, and the keys which changed, sorted by key.
+//
+// Subscribers are called before the next reload can start, so they mustn't call Reload.
+func (w *Watcher[T]) Subscribe(fn func(cfg T, changes []Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload loads the config again right away. If there's an error, the old config is kept.
+func (w *Watcher[T]) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if src, ok := w.loader.source.(reloader); ok {
+		if err := src.Reload(); err != nil {
+			return err
+		}
+	}
+	var next T
+	if err := w.loader.Load(&next, w.prefix); err != nil {
+		return err
+	}
+	old := w.current.Get()
+	changes, err := w.loader.Diff(&old, &next, w.prefix)
+	if err != nil {
+		return err
+	}
+	w.current.Set(next)
+	if len(changes) > 0 {
+		for _, subscriber := range w.subscribers {
+			subscriber(next, changes)
+		}
+	}
+	return nil
+}
+
+// Close stops watching for changes. The last config is still available from Get.
+func (w *Watcher[T]) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
+
+func (w *Watcher[T]) run() {
+	defer close(w.done)
+	defer signal.Stop(w.signals)
+	var ticks <-chan time.Time
+	if len(w.settings.files) > 0 {
+		ticker := time.NewTicker(w.settings.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.signals:
+			w.reloadOrLog()
+		case <-ticks:
+			w.mu.Lock()
+			changed := w.filesChanged()
+			w.mu.Unlock()
+			if changed {
+				w.reloadOrLog()
+			}
+		}
+	}
+}
+
+func (w *Watcher[T]) reloadOrLog() {
+	if err := w.Reload(); err != nil {
+		w.loader.logger.Printf("configs: failed to reload, so the old config is still in use: %v", err)
+	}
+}
+
+// filesChanged returns true if any of the watched files have changed since it was last called.
+// w.mu must be held by the caller.
+func (w *Watcher[T]) filesChanged() bool {
+	changed := false
+	for _, path := range w.settings.files {
+		var stamp fileStamp
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{true, info.ModTime(), info.Size()}
+		}
+		if old, ok := w.stamps[path]; !ok || old != stamp {
+			w.stamps[path] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,128 @@
+package configs_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	configs "github.com/wikisophia/go-environment-configs"
+)
+
+type WatchedConfig struct {
+	Port     int    `environment:"PORT"`
+	Host     string `environment:"HOST"`
+	Password string `environment:"PASSWORD"`
+}
+
+func writeFile(t *testing.T, path string, contents string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Some filesystems only store modification times to the second.
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newWatcher(t *testing.T, contents string, opts ...configs.WatchOption) (*configs.Watcher[WatchedConfig], string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.env")
+	writeFile(t, path, contents, time.Now().Add(-time.Hour))
+	src, err := configs.EnvFile(path)
+	if err != nil {
+		t.Fatalf("Got unexpected EnvFile() error: %v", err)
+	}
+	loader := configs.NewLoader(configs.WithSource(src), configs.WithLogger(&bufferLogger{}))
+	watcher, err := configs.Watch[WatchedConfig](loader, "MY", opts...)
+	if err != nil {
+		t.Fatalf("Got unexpected Watch() error: %v", err)
+	}
+	t.Cleanup(watcher.Close)
+	return watcher, path
+}
+
+func TestWatchFileChanges(t *testing.T) {
+	watcher, path := newWatcher(t, "MY_PORT=80\nMY_HOST=a\nMY_PASSWORD=old\n", configs.PollInterval(10*time.Millisecond))
+	assertIntsEqual(t, 80, watcher.Get().Port)
+
+	changed := make(chan []configs.Change, 1)
+	watcher.Subscribe(func(cfg WatchedConfig, changes []configs.Change) {
+		changed <- changes
+	})
+	writeFile(t, path, "MY_PORT=81\nMY_PASSWORD=new\n", time.Now())
+
+	select {
+	case changes := <-changed:
+		expected := []configs.Change{
+			{Key: "MY_HOST", Old: "a", New: ""},
+			{Key: "MY_PASSWORD", Old: "<redacted>", New: "<redacted>"},
+			{Key: "MY_PORT", Old: "80", New: "81"},
+		}
+		assertIntsEqual(t, len(expected), len(changes))
+		for i := 0; i < len(expected) && i < len(changes); i++ {
+			if expected[i] != changes[i] {
+				t.Errorf("Change %d: expected %#v, got %#v", i, expected[i], changes[i])
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the file change to be noticed")
+	}
+	assertIntsEqual(t, 81, watcher.Get().Port)
+	assertStringsEqual(t, "", watcher.Get().Host)
+}
+
+func TestWatchKeepsOldConfigOnError(t *testing.T) {
+	watcher, path := newWatcher(t, "MY_PORT=80\n", configs.ReloadOnSignals())
+	watcher.Subscribe(func(cfg WatchedConfig, changes []configs.Change) {
+		t.Errorf("Subscriber was called after a failed reload")
+	})
+
+	writeFile(t, path, "MY_PORT=eighty\n", time.Now())
+	if err := watcher.Reload(); err == nil {
+		t.Error("Missing expected Reload() error")
+	}
+	assertIntsEqual(t, 80, watcher.Get().Port)
+
+	os.Remove(path)
+	if err := watcher.Reload(); err == nil {
+		t.Error("Missing expected Reload() error")
+	}
+	assertIntsEqual(t, 80, watcher.Get().Port)
+}
+
+func TestWatchReloadsOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP can't be sent on windows")
+	}
+	// Polling would notice the change too, so make sure it doesn't get the chance.
+	watcher, path := newWatcher(t, "MY_PORT=80\n", configs.PollInterval(time.Hour))
+	changed := make(chan WatchedConfig, 1)
+	watcher.Subscribe(func(cfg WatchedConfig, changes []configs.Change) {
+		changed <- cfg
+	})
+	writeFile(t, path, "MY_PORT=81\n", time.Now())
+
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cfg := <-changed:
+		assertIntsEqual(t, 81, cfg.Port)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for SIGHUP to be noticed")
+	}
+}
+
+func TestWatchInitialError(t *testing.T) {
+	loader := configs.NewLoader(configs.WithSource(configs.MapSource(map[string]string{
+		"MY_PORT": "eighty",
+	})))
+	if _, err := configs.Watch[WatchedConfig](loader, "MY"); err == nil {
+		t.Error("Missing expected Watch() error")
+	}
+}